@@ -0,0 +1,13 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+data "smilecdr_node" "master" {
+  node_id = "Master"
+}
+
+output "fhir_endpoint_modules" {
+  value = [
+    for module in data.smilecdr_node.master.modules : module.module_id
+    if startswith(module.module_type, "ENDPOINT_FHIR_REST_")
+  ]
+}
@@ -0,0 +1,17 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+data "smilecdr_server_info" "master" {
+  node_id = "Master"
+}
+
+check "modules_started" {
+  assert {
+    condition     = data.smilecdr_server_info.master.all_modules_started
+    error_message = "Modules not started: ${join(", ", data.smilecdr_server_info.master.unhealthy_module_ids)}"
+  }
+}
+
+output "smilecdr_version" {
+  value = data.smilecdr_server_info.master.version
+}
@@ -0,0 +1,19 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+# reindex observations whenever the custom search parameter changes
+resource "smilecdr_batch_job" "reindex_observations" {
+  operation = "$reindex"
+
+  parameters = jsonencode({
+    resourceType = "Parameters"
+    parameter = [{
+      name        = "url"
+      valueString = "Observation?"
+    }]
+  })
+
+  triggers = {
+    search_parameter_version = smilecdr_search_parameter.observation_device.version_id
+  }
+}
@@ -0,0 +1,8 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_cda_template" "discharge_summary" {
+  name           = "discharge-summary"
+  template_file  = "${path.module}/templates/discharge_summary.hbs"
+  resource_types = ["Patient", "Encounter", "Condition", "MedicationStatement"]
+}
@@ -0,0 +1,32 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_fhir_bundle" "north_clinic" {
+  partition_name = "north-clinic"
+
+  bundle = jsonencode({
+    resourceType = "Bundle"
+    type         = "transaction"
+    entry = [
+      {
+        fullUrl = "urn:uuid:9c1e4b4e-3b8a-4c39-9a57-0d7d2f1c6a11"
+        resource = {
+          resourceType = "Organization"
+          name         = "North Street Clinic"
+        }
+        request = { method = "POST", url = "Organization" }
+      },
+      {
+        fullUrl = "urn:uuid:5b0f6f1d-7a51-4b77-8a0e-6c2b3f8e9d22"
+        resource = {
+          resourceType = "Location"
+          name         = "North Street Clinic, Room 1"
+          managingOrganization = {
+            reference = "urn:uuid:9c1e4b4e-3b8a-4c39-9a57-0d7d2f1c6a11"
+          }
+        }
+        request = { method = "POST", url = "Location" }
+      },
+    ]
+  })
+}
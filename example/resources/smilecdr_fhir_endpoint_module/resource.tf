@@ -0,0 +1,15 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_fhir_endpoint_module" "fhir_endpoint" {
+  module_id    = "fhir_endpoint"
+  fhir_version = "R4"
+  port         = 8000
+  base_url     = "https://fhir.example.com"
+
+  storage_module_id   = smilecdr_fhir_storage_module.persistence.id
+  security_module_ids = ["local_security"]
+
+  cors_enabled         = true
+  cors_allowed_origins = ["https://app.example.com"]
+}
@@ -0,0 +1,17 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_fhir_resource" "main_clinic" {
+  resource_type = "Organization"
+  resource_id   = "main-clinic"
+
+  body = jsonencode({
+    resourceType = "Organization"
+    active       = true
+    name         = "Main Street Clinic"
+    identifier = [{
+      system = "https://example.com/organizations"
+      value  = "main-clinic"
+    }]
+  })
+}
@@ -0,0 +1,20 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+variable "db_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "smilecdr_fhir_storage_module" "persistence" {
+  module_id    = "persistence"
+  fhir_version = "R4"
+
+  db_driver   = "POSTGRES_9_4"
+  db_url      = "jdbc:postgresql://localhost:5432/cdr"
+  db_username = "cdr"
+  db_password = var.db_password
+
+  partitioning_mode = "REQUEST_TENANT"
+  expunge_enabled   = true
+}
@@ -0,0 +1,10 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_mdm_rules" "mdm" {
+  module_id  = "mdm"
+  rules_json = file("${path.module}/rules/mdm_rules.json")
+
+  # rebuild the golden resources and links whenever the rules change
+  clear_and_resubmit = true
+}
@@ -0,0 +1,18 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_module_config" "audit_log" {
+  node_id     = "Master"
+  module_id   = "audit_log"
+  module_type = "AUDIT_LOG_PERSISTENCE"
+
+  options = {
+    "audit_log.enabled"        = "true"
+    "audit_log.retention_days" = "30"
+  }
+
+  dependencies {
+    module_id = "persistence"
+    type      = "CLUSTER_MGR"
+  }
+}
@@ -0,0 +1,13 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_module_state" "fhir_endpoint" {
+  module_id     = "fhir_endpoint"
+  desired_state = "STARTED"
+
+  # restart the endpoint whenever its configuration changes
+  restart_triggers = {
+    base_url = smilecdr_fhir_endpoint_module.fhir_endpoint.base_url
+    port     = smilecdr_fhir_endpoint_module.fhir_endpoint.port
+  }
+}
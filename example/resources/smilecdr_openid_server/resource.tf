@@ -0,0 +1,23 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+variable "keycloak_client_secret" {
+  type      = string
+  sensitive = true
+}
+
+resource "smilecdr_openid_server" "keycloak" {
+  name   = "Keycloak"
+  issuer = "https://keycloak.example.com/realms/cdr"
+
+  jwks_url          = "https://keycloak.example.com/realms/cdr/protocol/openid-connect/certs"
+  authorization_url = "https://keycloak.example.com/realms/cdr/protocol/openid-connect/auth"
+  token_url         = "https://keycloak.example.com/realms/cdr/protocol/openid-connect/token"
+  user_info_url     = "https://keycloak.example.com/realms/cdr/protocol/openid-connect/userinfo"
+
+  federation_client_id     = "smilecdr"
+  federation_client_secret = var.keycloak_client_secret
+  request_scopes           = ["openid", "profile"]
+
+  token_mapping_script_file = "${path.module}/scripts/keycloak_mapping.js"
+}
@@ -0,0 +1,18 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_package" "us_core" {
+  module_id = "persistence"
+  name      = "hl7.fhir.us.core"
+  version   = "6.1.0"
+
+  install_resource_types = ["StructureDefinition", "SearchParameter", "ValueSet", "CodeSystem"]
+  fetch_dependencies     = true
+}
+
+resource "smilecdr_package" "local_profiles" {
+  module_id    = "persistence"
+  name         = "example.fhir.profiles"
+  version      = "1.0.0"
+  package_file = "${path.module}/packages/example.fhir.profiles-1.0.0.tgz"
+}
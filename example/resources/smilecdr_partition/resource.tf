@@ -0,0 +1,8 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_partition" "north_clinic" {
+  partition_id = 1
+  name         = "north-clinic"
+  description  = "Data for the North Street clinic"
+}
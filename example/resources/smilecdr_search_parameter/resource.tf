@@ -0,0 +1,14 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_search_parameter" "patient_eye_colour" {
+  url         = "https://example.com/SearchParameter/patient-eye-colour"
+  code        = "eye-colour"
+  description = "Search patients by eye colour"
+  base        = ["Patient"]
+  type        = "token"
+  expression  = "Patient.extension('https://example.com/StructureDefinition/eye-colour').value"
+
+  # reindex existing patients so the new parameter finds them
+  reindex = true
+}
@@ -0,0 +1,7 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_security_callback_script" "local_security" {
+  module_id   = "local_security"
+  script_file = "${path.module}/scripts/local_security.js"
+}
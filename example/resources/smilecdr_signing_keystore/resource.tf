@@ -0,0 +1,21 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+# To roll the signing key over, add a new ACTIVE key and set the old one to RETIRING.
+# The retired key can be removed once token_lifetime_seconds have passed.
+resource "smilecdr_signing_keystore" "smart_auth" {
+  module_id              = "smart_auth"
+  token_lifetime_seconds = 3600
+
+  key {
+    kid       = "2026-10"
+    algorithm = "RS256"
+    status    = "ACTIVE"
+  }
+
+  key {
+    kid       = "2026-04"
+    algorithm = "RS256"
+    status    = "RETIRING"
+  }
+}
@@ -0,0 +1,15 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_smart_auth_module" "smart_auth" {
+  module_id          = "smart_auth"
+  issuer_url         = "https://auth.example.com"
+  port               = 9200
+  security_module_id = "local_security"
+
+  access_token_validity_seconds  = 300
+  refresh_token_validity_seconds = 86400
+
+  approval_page_enabled    = true
+  remember_approved_scopes = true
+}
@@ -0,0 +1,31 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+variable "webhook_token" {
+  type      = string
+  sensitive = true
+}
+
+resource "smilecdr_subscription" "finished_encounters" {
+  reason       = "Notify the billing service of finished encounters"
+  criteria     = "Encounter?status=finished"
+  channel_type = "rest-hook"
+  endpoint     = "https://billing.example.com/fhir/notify"
+  payload      = "application/fhir+json"
+
+  headers = {
+    Authorization = "Bearer ${var.webhook_token}"
+  }
+}
+
+# R5 subscriptions are topic-based and are created on an R5 FHIR endpoint
+resource "smilecdr_subscription" "admissions" {
+  fhir_version  = "R5"
+  fhir_base_url = "http://localhost:8001"
+
+  topic           = "https://example.com/SubscriptionTopic/admission"
+  filter_criteria = ["Encounter?class=IMP"]
+  channel_type    = "rest-hook"
+  endpoint        = "https://bed-management.example.com/fhir/notify"
+  content         = "id-only"
+}
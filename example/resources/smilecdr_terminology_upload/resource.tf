@@ -0,0 +1,11 @@
+# Copyright (c) Zed Werks Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+resource "smilecdr_terminology_upload" "loinc" {
+  system = "http://loinc.org"
+  files  = ["${path.module}/terminology/Loinc_2.76.zip"]
+
+  timeouts {
+    create = "6h"
+  }
+}
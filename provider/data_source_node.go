@@ -0,0 +1,141 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func dataSourceNode() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceNodeRead,
+		Schema: map[string]*schema.Schema{
+			"node_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"cluster_node_ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"modules": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"module_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"module_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"status": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"dependencies": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"module_id": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"type": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func flattenNodeModule(config smilecdr.ModuleConfig, status string) map[string]interface{} {
+	dependencies := make([]interface{}, len(config.Dependencies))
+	for i, dependency := range config.Dependencies {
+		dependencies[i] = map[string]interface{}{
+			"module_id": dependency.ModuleId,
+			"type":      dependency.Type,
+		}
+	}
+	return map[string]interface{}{
+		"module_id":    config.ModuleId,
+		"module_type":  config.ModuleType,
+		"status":       status,
+		"dependencies": dependencies,
+	}
+}
+
+func dataSourceNodeRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	nodeId := dataSourceDefault(d, c, "node_id")
+
+	statuses, err := c.GetNodeStatuses()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	status, ok := statuses[nodeId]
+	if !ok {
+		return diag.Errorf("node %s is not part of the cluster", nodeId)
+	}
+
+	clusterNodeIds := make([]string, 0, len(statuses))
+	for id := range statuses {
+		clusterNodeIds = append(clusterNodeIds, id)
+	}
+	sort.Strings(clusterNodeIds)
+
+	node, err := c.GetModuleConfigs(nodeId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// the node status already carries every module's status, merged across processes
+	moduleStatuses := make(map[string]string)
+	for _, module := range summarizeModuleHealth(status) {
+		moduleStatuses[module.ModuleId] = module.Status
+	}
+
+	var modules []interface{}
+	for _, config := range node.Modules {
+		if config.ArchivedAt != "" {
+			continue
+		}
+		modules = append(modules, flattenNodeModule(config, moduleStatuses[config.ModuleId]))
+	}
+
+	d.SetId(nodeId)
+	d.Set("version", status.Version())
+	d.Set("cluster_node_ids", clusterNodeIds)
+	if err := d.Set("modules", modules); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
+}
@@ -0,0 +1,152 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func dataSourceServerInfo() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceServerInfoRead,
+		Schema: map[string]*schema.Schema{
+			"node_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"build": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"start_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"uptime_seconds": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"all_modules_started": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"unhealthy_module_ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"modules": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"module_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"status": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"failure_message": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// summarizeModuleHealth merges the module statuses of a node's processes: a module
+// is only STARTED when it runs in every process, otherwise the first other status
+// is reported.
+func summarizeModuleHealth(node smilecdr.NodeStatus) []smilecdr.ModuleStatus {
+	summary := make(map[string]smilecdr.ModuleStatus)
+	for _, process := range node.SortedProcesses() {
+		for _, module := range process.Modules {
+			current, seen := summary[module.ModuleId]
+			if !seen || (current.Status == smilecdr.ModuleStatusStarted && module.Status != smilecdr.ModuleStatusStarted) {
+				summary[module.ModuleId] = module
+			}
+		}
+	}
+
+	modules := make([]smilecdr.ModuleStatus, 0, len(summary))
+	for _, module := range summary {
+		modules = append(modules, module)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].ModuleId < modules[j].ModuleId
+	})
+	return modules
+}
+
+func dataSourceServerInfoRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	nodeId := dataSourceDefault(d, c, "node_id")
+
+	statuses, err := c.GetNodeStatuses()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	node, ok := statuses[nodeId]
+	if !ok {
+		return diag.Errorf("node %s is not part of the cluster", nodeId)
+	}
+
+	startedAt, err := node.StartedAt()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	modules := []interface{}{}
+	unhealthy := []string{}
+	for _, module := range summarizeModuleHealth(node) {
+		modules = append(modules, map[string]interface{}{
+			"module_id":       module.ModuleId,
+			"status":          module.Status,
+			"failure_message": module.FailureMessage,
+		})
+		if module.Status != smilecdr.ModuleStatusStarted {
+			unhealthy = append(unhealthy, module.ModuleId)
+		}
+	}
+
+	d.SetId(nodeId)
+	d.Set("version", node.Version())
+	d.Set("build", node.Build())
+	if startedAt.IsZero() {
+		d.Set("start_time", "")
+		d.Set("uptime_seconds", 0)
+	} else {
+		d.Set("start_time", startedAt.Format(time.RFC3339))
+		d.Set("uptime_seconds", int(time.Since(startedAt).Seconds()))
+	}
+	d.Set("all_modules_started", len(unhealthy) == 0)
+	d.Set("unhealthy_module_ids", unhealthy)
+	if err := d.Set("modules", modules); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
+}
@@ -0,0 +1,66 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+// providerDefault returns the provider's default_node_id or default_module_id for
+// the node_id and module_id attributes.
+func providerDefault(c *smilecdr.Client, attribute string) string {
+	switch attribute {
+	case "node_id":
+		return c.DefaultNodeId()
+	case "module_id":
+		return c.DefaultModuleId()
+	}
+	return ""
+}
+
+// inheritProviderDefaults returns a CustomizeDiffFunc that plans node_id and
+// module_id from the provider defaults when the configuration leaves them out. The
+// attributes are Optional, Computed and ForceNew, so only resources being created
+// inherit the current default. Existing resources keep the value in their state,
+// and changing a provider default never replaces them.
+func inheritProviderDefaults(attributes ...string) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+		c, ok := m.(*smilecdr.Client)
+		if !ok {
+			return nil
+		}
+
+		config := d.GetRawConfig()
+		for _, attribute := range attributes {
+			configured := d.Get(attribute).(string) != ""
+			if !config.IsNull() && config.IsKnown() {
+				configured = !config.GetAttr(attribute).IsNull()
+			}
+			if configured || (d.Id() != "" && d.Get(attribute).(string) != "") {
+				continue
+			}
+			value := providerDefault(c, attribute)
+			if d.Get(attribute).(string) != value {
+				if err := d.SetNew(attribute, value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// dataSourceDefault returns the configured value of a data source's node_id or
+// module_id, falling back to the provider default.
+func dataSourceDefault(d *schema.ResourceData, c *smilecdr.Client, attribute string) string {
+	if value := d.Get(attribute).(string); value != "" {
+		return value
+	}
+	value := providerDefault(c, attribute)
+	d.Set(attribute, value)
+	return value
+}
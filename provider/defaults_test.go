@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func Test_inheritProviderDefaults(t *testing.T) {
+	c := smilecdr.NewClient("http://localhost:9000", "admin", "password")
+	c.SetDefaults("node1", "smart_auth_external")
+
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{"module_id": "fhir_endpoint"})
+	diff, err := resourceModuleState().Diff(context.Background(), nil, config, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := diff.Attributes["node_id"].New; got != "node1" {
+		t.Fatalf("expected node_id to inherit node1, got %q", got)
+	}
+
+	state := &terraform.InstanceState{
+		ID: "node1/fhir_endpoint",
+		Attributes: map[string]string{
+			"id":            "node1/fhir_endpoint",
+			"node_id":       "node1",
+			"module_id":     "fhir_endpoint",
+			"desired_state": smilecdr.ModuleStatusStarted,
+			"status":        smilecdr.ModuleStatusStarted,
+		},
+	}
+	diff, err = resourceModuleState().Diff(context.Background(), state, config, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff != nil && diff.Attributes["node_id"] != nil {
+		t.Fatalf("expected no diff for an inherited node_id, got %+v", diff.Attributes["node_id"])
+	}
+
+	// a new provider default only applies to new resources
+	c.SetDefaults("node2", "smart_auth_external")
+	diff, err = resourceModuleState().Diff(context.Background(), state, config, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff != nil && diff.Attributes["node_id"] != nil {
+		t.Fatalf("expected the existing resource to keep node1, got %+v", diff.Attributes["node_id"])
+	}
+}
@@ -5,9 +5,12 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/zed-werks/terraform-smilecdr/smilecdr"
 )
 
@@ -15,10 +18,10 @@ func Provider() *schema.Provider {
 	return &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"base_url": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Default:     "http://localhost:9000",
-				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_BASE_URL", nil),
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("SMILECDR_BASE_URL", "http://localhost:9000"),
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
 			},
 			"username": {
 				Type:        schema.TypeString,
@@ -31,29 +34,163 @@ func Provider() *schema.Provider {
 				Sensitive:   true,
 				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_PASSWORD", nil),
 			},
+			"fhir_base_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("SMILECDR_FHIR_BASE_URL", "http://localhost:8000"),
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"fhir_username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_FHIR_USERNAME", nil),
+			},
+			"fhir_password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_FHIR_PASSWORD", nil),
+			},
+			"default_node_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_DEFAULT_NODE_ID", "Master"),
+			},
+			"default_module_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_DEFAULT_MODULE_ID", "smart_auth"),
+			},
+			"verify_credentials": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_VERIFY_CREDENTIALS", false),
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"smilecdr_openid_client": resourceOpenIdClient(),
+			"smilecdr_openid_client":            resourceOpenIdClient(),
+			"smilecdr_module_config":            resourceModuleConfig(),
+			"smilecdr_fhir_storage_module":      resourceFhirStorageModule(),
+			"smilecdr_fhir_endpoint_module":     resourceFhirEndpointModule(),
+			"smilecdr_smart_auth_module":        resourceSmartAuthModule(),
+			"smilecdr_module_state":             resourceModuleState(),
+			"smilecdr_openid_server":            resourceOpenIdServer(),
+			"smilecdr_security_callback_script": resourceSecurityCallbackScript(),
+			"smilecdr_signing_keystore":         resourceSigningKeystore(),
+			"smilecdr_fhir_resource":            resourceFhirResource(),
+			"smilecdr_search_parameter":         resourceSearchParameter(),
+			"smilecdr_subscription":             resourceSubscription(),
+			"smilecdr_partition":                resourcePartition(),
+			"smilecdr_fhir_bundle":              resourceFhirBundle(),
+			"smilecdr_package":                  resourcePackage(),
+			"smilecdr_terminology_upload":       resourceTerminologyUpload(),
+			"smilecdr_mdm_rules":                resourceMdmRules(),
+			"smilecdr_cda_template":             resourceCdaTemplate(),
+			"smilecdr_batch_job":                resourceBatchJob(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"smilecdr_node":        dataSourceNode(),
+			"smilecdr_server_info": dataSourceServerInfo(),
 		},
-		DataSourcesMap:       map[string]*schema.Resource{},
 		ConfigureContextFunc: providerConfigure,
 	}
 }
 
+// missingSetting reports a provider setting that is neither configured nor set in
+// its environment variable.
+func missingSetting(attribute string, envVar string) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf("%s not set", envVar),
+		Detail:   fmt.Sprintf("Set %s in the provider configuration or the %s environment variable.", attribute, envVar),
+	}
+}
+
+// checkCredentials describes why the lightweight authenticated call made by
+// verify_credentials failed, e.g. "401 from /runtime-status/node-statuses/complete".
+func checkCredentials(err error) diag.Diagnostic {
+	if apiErr, ok := err.(*smilecdr.ApiError); ok {
+		detail := "The Smile CDR Admin API rejected the request."
+		switch apiErr.StatusCode {
+		case http.StatusUnauthorized:
+			detail = "Check username and password, or SMILECDR_USERNAME and SMILECDR_PASSWORD."
+		case http.StatusForbidden:
+			detail = "The user needs the VIEW_MODULE_STATUS permission to read the runtime status."
+		}
+		return diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("%d from %s", apiErr.StatusCode, apiErr.Endpoint),
+			Detail:   detail,
+		}
+	}
+	return diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  "Unable to reach the Smile CDR Admin API",
+		Detail:   err.Error(),
+	}
+}
+
+// providerClient returns the client built by providerConfigure, or an error when
+// the provider has not been configured.
+func providerClient(m interface{}) (*smilecdr.Client, error) {
+	c, ok := m.(*smilecdr.Client)
+	if !ok || c == nil {
+		return nil, fmt.Errorf("the Smile CDR provider is not configured")
+	}
+	return c, nil
+}
+
+// clientFrom is providerClient for the CRUD functions, which report diagnostics.
+func clientFrom(m interface{}) (*smilecdr.Client, diag.Diagnostics) {
+	c, err := providerClient(m)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	return c, nil
+}
+
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 
 	var diags diag.Diagnostics
-	var baseUrl string
 
+	baseUrl := d.Get("base_url").(string)
 	username := d.Get("username").(string)
 	password := d.Get("password").(string)
-	baseUrl = d.Get("base_url").(string)
 
-	if (baseUrl != "") && (username != "") && (password != "") {
-		c := smilecdr.NewClient(baseUrl, username, password)
+	if username == "" {
+		diags = append(diags, missingSetting("username", "SMILECDR_USERNAME"))
+	}
+	if password == "" {
+		diags = append(diags, missingSetting("password", "SMILECDR_PASSWORD"))
+	}
+
+	// the FHIR endpoint falls back to the admin credentials when it has none of its own
+	fhirUsername := d.Get("fhir_username").(string)
+	fhirPassword := d.Get("fhir_password").(string)
+	switch {
+	case fhirUsername == "" && fhirPassword == "":
+		fhirUsername, fhirPassword = username, password
+	case fhirUsername == "":
+		diags = append(diags, missingSetting("fhir_username", "SMILECDR_FHIR_USERNAME"))
+	case fhirPassword == "":
+		diags = append(diags, missingSetting("fhir_password", "SMILECDR_FHIR_PASSWORD"))
+	}
+
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	c := smilecdr.NewClient(baseUrl, username, password)
+	c.SetFhirClient(smilecdr.NewFhirClient(d.Get("fhir_base_url").(string), fhirUsername, fhirPassword))
+	c.SetDefaults(d.Get("default_node_id").(string), d.Get("default_module_id").(string))
 
-		return c, diags
+	// the version is detected when a resource first needs it, this only checks the
+	// credentials with the same lightweight call
+	if d.Get("verify_credentials").(bool) {
+		if _, err := c.GetNodeStatuses(); err != nil {
+			return nil, append(diags, checkCredentials(err))
+		}
 	}
 
-	return nil, diags
+	return c, diags
 }
@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func Test_providerConfigureMissingPassword(t *testing.T) {
+	t.Setenv("SMILECDR_PASSWORD", "")
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"base_url": "http://localhost:9000",
+		"username": "admin",
+	})
+
+	c, diags := providerConfigure(context.Background(), d)
+	if c != nil || !diags.HasError() {
+		t.Fatalf("expected an error and no client, got %v %v", c, diags)
+	}
+	if diags[0].Summary != "SMILECDR_PASSWORD not set" {
+		t.Fatalf("unexpected diagnostic: %s", diags[0].Summary)
+	}
+}
+
+func Test_providerConfigureVerifyCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"base_url":           server.URL,
+		"username":           "admin",
+		"password":           "wrong",
+		"verify_credentials": true,
+	})
+
+	_, diags := providerConfigure(context.Background(), d)
+	if !diags.HasError() || diags[0].Summary != "401 from /runtime-status/node-statuses/complete" {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+}
+
+func Test_clientFromUnconfiguredProvider(t *testing.T) {
+	c, diags := clientFrom(nil)
+	if c != nil || !diags.HasError() {
+		t.Fatalf("expected an error and no client, got %v %v", c, diags)
+	}
+}
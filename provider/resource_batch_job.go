@@ -0,0 +1,198 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+// batchJobOperationPattern matches system and type level operations, e.g. "$reindex"
+// or "Patient/$export".
+var batchJobOperationPattern = regexp.MustCompile(`^([A-Z][A-Za-z]*/)?\$[a-z][a-z0-9\-.]*$`)
+
+func resourceBatchJob() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceBatchJobCreate,
+		ReadContext:   resourceBatchJobRead,
+		DeleteContext: resourceBatchJobDelete,
+		Schema: map[string]*schema.Schema{
+			"operation": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+				ValidateFunc: validation.StringMatch(batchJobOperationPattern,
+					"must be an operation such as $reindex or Patient/$export"),
+			},
+			"parameters": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsJSON,
+			},
+			"partition_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"job_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"report": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+		},
+	}
+}
+
+// waitForBatchJob polls a batch job until it completes. A FAILED or CANCELLED job
+// is reported with the job's error message.
+func waitForBatchJob(ctx context.Context, c *smilecdr.Client, instanceId string, timeout time.Duration) error {
+	_, err := waitForBatchJobResult(ctx, c, instanceId, timeout)
+	return err
+}
+
+func waitForBatchJobResult(ctx context.Context, c *smilecdr.Client, instanceId string, timeout time.Duration) (smilecdr.BatchJob, error) {
+	var last smilecdr.BatchJob
+
+	stateConf := &retry.StateChangeConf{
+		Pending: []string{
+			smilecdr.BatchJobStatusQueued,
+			smilecdr.BatchJobStatusInProgress,
+			smilecdr.BatchJobStatusFinalize,
+			smilecdr.BatchJobStatusErrored,
+		},
+		Target: []string{smilecdr.BatchJobStatusCompleted},
+		Refresh: func() (interface{}, string, error) {
+			job, err := c.GetBatchJob(instanceId)
+			if err != nil {
+				return nil, "", err
+			}
+			last = job
+			if job.Status == smilecdr.BatchJobStatusFailed || job.Status == smilecdr.BatchJobStatusCancelled {
+				return job, job.Status, fmt.Errorf("batch job %s is %s: %s", instanceId, job.Status, job.ErrorMessage)
+			}
+			return job, job.Status, nil
+		},
+		Timeout:    timeout,
+		Delay:      2 * time.Second,
+		MinTimeout: 5 * time.Second,
+	}
+
+	_, err := stateConf.WaitForStateContext(ctx)
+	if err != nil && !last.IsFinal() && last.ErrorMessage != "" {
+		// an ERRORED job is retried by the server, its last error explains a timeout
+		return last, fmt.Errorf("%s (last status %s: %s)", err, last.Status, last.ErrorMessage)
+	}
+	return last, err
+}
+
+func resourceBatchJobCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := fhirClientForResource(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	parameters := smilecdr.NewParameters()
+	if text := d.Get("parameters").(string); text != "" {
+		if err := json.Unmarshal([]byte(text), &parameters); err != nil {
+			return diag.Errorf("parameters: %s", err)
+		}
+		if parameters.ResourceType != "Parameters" {
+			return diag.Errorf("parameters: expected a Parameters resource, got %s", parameters.ResourceType)
+		}
+	}
+
+	jobId, err := fhir.StartJob(d.Get("operation").(string), parameters)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(id.UniqueId())
+	d.Set("job_id", jobId)
+
+	// operations such as $expunge complete before they return
+	if jobId == "" {
+		d.Set("status", smilecdr.BatchJobStatusCompleted)
+		return resourceBatchJobRead(ctx, d, m)
+	}
+
+	job, err := waitForBatchJobResult(ctx, c, jobId, d.Timeout(schema.TimeoutCreate))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.Set("status", job.Status)
+	d.Set("report", job.Report)
+
+	return resourceBatchJobRead(ctx, d, m)
+}
+
+func resourceBatchJobRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	jobId := d.Get("job_id").(string)
+	if jobId == "" {
+		return diags
+	}
+
+	// finished jobs are eventually purged, the resource only records that the job ran
+	job, err := c.GetBatchJob(jobId)
+	if smilecdr.IsNotFound(err) {
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("status", job.Status)
+	d.Set("report", job.Report)
+
+	return diags
+}
+
+func resourceBatchJobDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	// the job has run, only Terraform stops tracking it
+	d.SetId("")
+
+	return diags
+}
@@ -0,0 +1,203 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/provider/util"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceCdaTemplate() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceCdaTemplateCreate,
+		ReadContext:   resourceCdaTemplateRead,
+		UpdateContext: resourceCdaTemplateUpdate,
+		DeleteContext: resourceCdaTemplateDelete,
+		CustomizeDiff: resourceCdaTemplateCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+			},
+			"template_file": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"resource_types": {
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"content_hash": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// cdaTemplateHash covers the template and the resource types it uses, so a change
+// to either uploads the template again. The resource types are hashed in sorted
+// order, as the server may return them in any order.
+func cdaTemplateHash(template string, resourceTypes []string) string {
+	sorted := append([]string(nil), resourceTypes...)
+	sort.Strings(sorted)
+	return util.HashContent(template + "\x00" + strings.Join(sorted, ","))
+}
+
+func resourceCdaTemplateCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("template_file") || !d.NewValueKnown("resource_types") {
+		return d.SetNewComputed("content_hash")
+	}
+
+	content, err := os.ReadFile(d.Get("template_file").(string))
+	if err != nil {
+		return fmt.Errorf("template_file: %s", err)
+	}
+
+	hash := cdaTemplateHash(string(content), stringsFromSet(d.Get("resource_types").(*schema.Set)))
+	if d.Get("content_hash").(string) != hash {
+		return d.SetNew("content_hash", hash)
+	}
+	return nil
+}
+
+func resourceDataToCdaTemplate(d *schema.ResourceData) (smilecdr.CdaTemplate, error) {
+	template := smilecdr.CdaTemplate{
+		Name:          d.Get("name").(string),
+		ResourceTypes: stringsFromSet(d.Get("resource_types").(*schema.Set)),
+	}
+	content, err := os.ReadFile(d.Get("template_file").(string))
+	if err != nil {
+		return template, fmt.Errorf("template_file: %s", err)
+	}
+	template.Template = string(content)
+	return template, nil
+}
+
+// uploadCdaTemplate creates the template, or overwrites it in place when it exists.
+func uploadCdaTemplate(d *schema.ResourceData, fhir *smilecdr.FhirClient, exists bool) error {
+	template, err := resourceDataToCdaTemplate(d)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		err = fhir.PutCdaTemplate(template)
+	} else {
+		err = fhir.PostCdaTemplate(template)
+	}
+	if err != nil {
+		return err
+	}
+
+	d.Set("content_hash", cdaTemplateHash(template.Template, template.ResourceTypes))
+
+	return nil
+}
+
+func resourceCdaTemplateCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := uploadCdaTemplate(d, fhir, false); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(d.Get("name").(string))
+
+	return resourceCdaTemplateRead(ctx, d, m)
+}
+
+func resourceCdaTemplateRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	template, err := fhir.GetCdaTemplate(d.Id())
+	if err != nil {
+		if smilecdr.IsNotFound(err) {
+			d.SetId("")
+			return diags
+		}
+		return diag.FromErr(err)
+	}
+
+	// template_file stays as configured, a server-side change to the template shows
+	// as a content_hash that no longer matches the local file
+	d.Set("name", d.Id())
+	d.Set("resource_types", template.ResourceTypes)
+	d.Set("content_hash", cdaTemplateHash(template.Template, template.ResourceTypes))
+
+	return diags
+}
+
+func resourceCdaTemplateUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// the template is overwritten in place, a template is only deleted when its name
+	// changes, which replaces the resource
+	if d.HasChange("content_hash") {
+		if err := uploadCdaTemplate(d, fhir, true); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceCdaTemplateRead(ctx, d, m)
+}
+
+func resourceCdaTemplateDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := fhir.DeleteCdaTemplate(d.Get("name").(string)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
@@ -0,0 +1,453 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/provider/util"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceFhirBundle() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceFhirBundleCreate,
+		ReadContext:   resourceFhirBundleRead,
+		UpdateContext: resourceFhirBundleUpdate,
+		DeleteContext: resourceFhirBundleDelete,
+		CustomizeDiff: resourceFhirBundleCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"bundle": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: structure.SuppressJsonDiff,
+			},
+			"partition_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"member": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"full_url": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"resource_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"resource_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"version_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"created": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"applied_versions": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+// bundleMember is a resource written by the bundle. created is only set for
+// resources the bundle created, conditional creates that matched an existing
+// resource are left alone on destroy.
+type bundleMember struct {
+	fullUrl      string
+	resourceType string
+	resourceId   string
+	versionId    string
+	created      bool
+}
+
+func (member bundleMember) reference() string {
+	return member.resourceType + "/" + member.resourceId
+}
+
+func bundleMembersFromList(list []interface{}) []bundleMember {
+	members := make([]bundleMember, 0, len(list))
+	for _, item := range list {
+		member := item.(map[string]interface{})
+		members = append(members, bundleMember{
+			fullUrl:      member["full_url"].(string),
+			resourceType: member["resource_type"].(string),
+			resourceId:   member["resource_id"].(string),
+			versionId:    member["version_id"].(string),
+			created:      member["created"].(bool),
+		})
+	}
+	return members
+}
+
+func flattenBundleMembers(members []bundleMember) []interface{} {
+	list := make([]interface{}, 0, len(members))
+	for _, member := range members {
+		list = append(list, map[string]interface{}{
+			"full_url":      member.fullUrl,
+			"resource_type": member.resourceType,
+			"resource_id":   member.resourceId,
+			"version_id":    member.versionId,
+			"created":       member.created,
+		})
+	}
+	return list
+}
+
+// bundleEntryKey identifies an entry across changes to the bundle, by fullUrl when
+// it has one and by position otherwise.
+func bundleEntryKey(fullUrl string, index int) string {
+	if fullUrl != "" {
+		return fullUrl
+	}
+	return "#" + strconv.Itoa(index)
+}
+
+// matchBundleMembers maps the bundle's entries, by index, to the previous members
+// they wrote. A member is only reused for an entry of the same resource type, so an
+// entry without a fullUrl that moved after an insert or removal is created anew
+// rather than written over another resource.
+func matchBundleMembers(bundle smilecdr.Bundle, previousMembers []bundleMember) map[int]bundleMember {
+	previous := make(map[string]bundleMember)
+	for i, member := range previousMembers {
+		previous[bundleEntryKey(member.fullUrl, i)] = member
+	}
+
+	matched := make(map[int]bundleMember)
+	for i, entry := range bundle.Entry {
+		member, ok := previous[bundleEntryKey(entry.FullUrl, i)]
+		if !ok {
+			continue
+		}
+		meta, err := smilecdr.ParseResourceMeta(entry.Resource)
+		if err != nil || meta.ResourceType != member.resourceType {
+			continue
+		}
+		matched[i] = member
+	}
+	return matched
+}
+
+func parseTransactionBundle(text string) (smilecdr.Bundle, error) {
+	var bundle smilecdr.Bundle
+	if err := json.Unmarshal([]byte(text), &bundle); err != nil {
+		return bundle, err
+	}
+	if bundle.ResourceType != "Bundle" || bundle.Type != "transaction" {
+		return bundle, fmt.Errorf("expected a Bundle of type transaction, got %s of type %q", bundle.ResourceType, bundle.Type)
+	}
+	for i, entry := range bundle.Entry {
+		if entry.Request == nil || (entry.Request.Method != http.MethodPost && entry.Request.Method != http.MethodPut) {
+			return bundle, fmt.Errorf("entry %d: only POST and PUT requests are supported", i)
+		}
+		if len(entry.Resource) == 0 {
+			return bundle, fmt.Errorf("entry %d: has no resource", i)
+		}
+	}
+	return bundle, nil
+}
+
+func resourceFhirBundleCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("bundle") {
+		return nil
+	}
+	if _, err := parseTransactionBundle(d.Get("bundle").(string)); err != nil {
+		return fmt.Errorf("bundle: %s", err)
+	}
+
+	drifted := d.HasChange("bundle")
+	applied := d.Get("applied_versions").(map[string]interface{})
+	for _, member := range bundleMembersFromList(d.Get("member").([]interface{})) {
+		if applied[member.reference()] != member.versionId {
+			drifted = true
+		}
+	}
+	if !drifted {
+		return nil
+	}
+	if err := d.SetNewComputed("member"); err != nil {
+		return err
+	}
+	return d.SetNewComputed("applied_versions")
+}
+
+func applyFhirBundle(d *schema.ResourceData, fhir *smilecdr.FhirClient) error {
+
+	bundle, err := parseTransactionBundle(d.Get("bundle").(string))
+	if err != nil {
+		return err
+	}
+
+	oldBundle, _ := d.GetChange("bundle")
+	oldMembers, _ := d.GetChange("member")
+	previousMembers := bundleMembersFromList(oldMembers.([]interface{}))
+	matched := matchBundleMembers(bundle, previousMembers)
+
+	// members that already exist are written back in place, so a re-apply restores
+	// drifted members rather than matching them with ifNoneExist or creating copies
+	for i, entry := range bundle.Entry {
+		member, ok := matched[i]
+		if !ok || entry.Request.Method != http.MethodPost {
+			continue
+		}
+		resource, err := util.FhirResourceWithId(string(entry.Resource), member.resourceId)
+		if err != nil {
+			return fmt.Errorf("entry %d: %s", i, err)
+		}
+		bundle.Entry[i].Resource = resource
+		bundle.Entry[i].Request = &smilecdr.BundleEntryRequest{Method: http.MethodPut, Url: member.reference()}
+	}
+
+	response, err := fhir.Transaction(bundle)
+	if err != nil {
+		return err
+	}
+	if len(response.Entry) != len(bundle.Entry) {
+		return fmt.Errorf("transaction returned %d entries for %d requests", len(response.Entry), len(bundle.Entry))
+	}
+
+	members := make([]bundleMember, 0, len(bundle.Entry))
+	current := make(map[string]bool)
+	applied := make(map[string]interface{})
+	for i, entry := range response.Entry {
+		if entry.Response == nil {
+			return fmt.Errorf("entry %d: transaction response has no location", i)
+		}
+		resourceType, resourceId, versionId := entry.Response.ParseLocation()
+		member := bundleMember{
+			fullUrl:      bundle.Entry[i].FullUrl,
+			resourceType: resourceType,
+			resourceId:   resourceId,
+			versionId:    versionId,
+			created:      matched[i].created || strings.HasPrefix(entry.Response.Status, "201"),
+		}
+		members = append(members, member)
+		current[member.reference()] = true
+		applied[member.reference()] = versionId
+	}
+
+	// members dropped from the bundle are deleted, dependents first
+	var removed []bundleMember
+	for _, member := range previousMembers {
+		if !current[member.reference()] {
+			removed = append(removed, member)
+		}
+	}
+
+	// removed members stay in state without an applied version until they are
+	// deleted, so a failed delete shows as drift and is retried on the next apply
+	d.Set("member", flattenBundleMembers(append(members, removed...)))
+	d.Set("applied_versions", applied)
+
+	remaining, err := deleteBundleMembers(fhir, removed, bundleMemberResources(oldBundle.(string), previousMembers))
+	if err != nil {
+		d.Set("member", flattenBundleMembers(append(members, remaining...)))
+		return err
+	}
+	d.Set("member", flattenBundleMembers(members))
+
+	return nil
+}
+
+// bundleDeleteOrder orders members so that every member is deleted before the
+// members it references. Members in a reference cycle go last, in reverse order.
+func bundleDeleteOrder(members []bundleMember, resources map[string]json.RawMessage) []bundleMember {
+	index := make(map[string]int)
+	for i, member := range members {
+		index[member.reference()] = i
+		if member.fullUrl != "" {
+			index[member.fullUrl] = i
+		}
+	}
+
+	references := make([][]int, len(members))
+	referencedBy := make([]int, len(members))
+	for i, member := range members {
+		for _, reference := range util.FhirReferences(resources[member.reference()]) {
+			if j, ok := index[reference]; ok && j != i {
+				references[i] = append(references[i], j)
+				referencedBy[j]++
+			}
+		}
+	}
+
+	ordered := make([]bundleMember, 0, len(members))
+	done := make([]bool, len(members))
+	for progress := true; progress; {
+		progress = false
+		for i := len(members) - 1; i >= 0; i-- {
+			if done[i] || referencedBy[i] > 0 {
+				continue
+			}
+			done[i] = true
+			progress = true
+			ordered = append(ordered, members[i])
+			for _, j := range references[i] {
+				referencedBy[j]--
+			}
+		}
+	}
+	for i := len(members) - 1; i >= 0; i-- {
+		if !done[i] {
+			ordered = append(ordered, members[i])
+		}
+	}
+	return ordered
+}
+
+// bundleMemberResources maps each member to the resource the bundle wrote for it.
+// Members are kept in bundle order, so they line up with the bundle's entries.
+// Members still waiting to be deleted follow the bundle's entries and map to nothing.
+func bundleMemberResources(text string, members []bundleMember) map[string]json.RawMessage {
+	resources := make(map[string]json.RawMessage)
+	bundle, err := parseTransactionBundle(text)
+	if err != nil || len(bundle.Entry) > len(members) {
+		return resources
+	}
+	for i, entry := range bundle.Entry {
+		resources[members[i].reference()] = entry.Resource
+	}
+	return resources
+}
+
+// deleteBundleMembers deletes the members the bundle created, dependents first. On
+// failure it returns the created members that were not deleted.
+func deleteBundleMembers(fhir *smilecdr.FhirClient, members []bundleMember, resources map[string]json.RawMessage) ([]bundleMember, error) {
+	var created []bundleMember
+	for _, member := range bundleDeleteOrder(members, resources) {
+		if member.created {
+			created = append(created, member)
+		}
+	}
+	for i, member := range created {
+		if err := fhir.Delete(member.resourceType, member.resourceId); err != nil {
+			return created[i:], fmt.Errorf("deleting %s: %s", member.reference(), err)
+		}
+	}
+	return nil, nil
+}
+
+func resourceFhirBundleCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	fhir, err := fhirClientForResource(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := applyFhirBundle(d, fhir); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(id.UniqueId())
+
+	return resourceFhirBundleRead(ctx, d, m)
+}
+
+func resourceFhirBundleRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	fhir, err := fhirClientForResource(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	members := bundleMembersFromList(d.Get("member").([]interface{}))
+	if len(members) == 0 {
+		return diags
+	}
+
+	// read every member in one batch, members that are gone read back without a version
+	batch := smilecdr.Bundle{ResourceType: "Bundle", Type: "batch"}
+	for _, member := range members {
+		batch.Entry = append(batch.Entry, smilecdr.BundleEntry{
+			Request: &smilecdr.BundleEntryRequest{Method: http.MethodGet, Url: member.reference()},
+		})
+	}
+	response, err := fhir.Transaction(batch)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	for i := range members {
+		members[i].versionId = ""
+		if i >= len(response.Entry) || len(response.Entry[i].Resource) == 0 {
+			continue
+		}
+		if entry := response.Entry[i]; entry.Response != nil && strings.HasPrefix(entry.Response.Status, "200") {
+			meta, err := smilecdr.ParseResourceMeta(entry.Resource)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			members[i].versionId = meta.Meta.VersionId
+		}
+	}
+
+	d.Set("member", flattenBundleMembers(members))
+
+	return diags
+}
+
+func resourceFhirBundleUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	fhir, err := fhirClientForResource(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := applyFhirBundle(d, fhir); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceFhirBundleRead(ctx, d, m)
+}
+
+func resourceFhirBundleDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	fhir, err := fhirClientForResource(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	members := bundleMembersFromList(d.Get("member").([]interface{}))
+	resources := bundleMemberResources(d.Get("bundle").(string), members)
+
+	if remaining, err := deleteBundleMembers(fhir, members, resources); err != nil {
+		d.Set("member", flattenBundleMembers(remaining))
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
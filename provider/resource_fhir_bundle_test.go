@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func Test_deleteBundleMembersReturnsRemaining(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/Patient/1" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	members := []bundleMember{
+		{resourceType: "Patient", resourceId: "1", created: true},
+		{resourceType: "Organization", resourceId: "3"},
+		{resourceType: "Observation", resourceId: "2", created: true},
+	}
+	fhir := smilecdr.NewFhirClient(server.URL, "admin", "password")
+	remaining, err := deleteBundleMembers(fhir, members, nil)
+	if err == nil {
+		t.Fatal("expected the Patient delete to fail")
+	}
+	if len(deleted) != 1 || deleted[0] != "/Observation/2" {
+		t.Fatalf("unexpected deletes %v", deleted)
+	}
+	if len(remaining) != 1 || remaining[0].reference() != "Patient/1" {
+		t.Fatalf("unexpected remaining members %v", remaining)
+	}
+}
+
+func Test_matchBundleMembersByResourceType(t *testing.T) {
+	previous := []bundleMember{
+		{resourceType: "Patient", resourceId: "1"},
+		{resourceType: "Observation", resourceId: "2"},
+	}
+	// an Organization was inserted first, shifting the unnamed entries
+	bundle := smilecdr.Bundle{Entry: []smilecdr.BundleEntry{
+		{Resource: []byte(`{"resourceType":"Organization"}`)},
+		{Resource: []byte(`{"resourceType":"Patient"}`)},
+		{Resource: []byte(`{"resourceType":"Observation"}`)},
+	}}
+
+	matched := matchBundleMembers(bundle, previous)
+	if len(matched) != 0 {
+		t.Fatalf("expected shifted entries of another type to be created anew, got %v", matched)
+	}
+
+	bundle.Entry = bundle.Entry[1:]
+	matched = matchBundleMembers(bundle, previous)
+	if matched[0].reference() != "Patient/1" || matched[1].reference() != "Observation/2" {
+		t.Fatalf("expected entries to keep their members, got %v", matched)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceFhirEndpointModule() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceFhirEndpointModuleCreate,
+		ReadContext:   resourceFhirEndpointModuleRead,
+		UpdateContext: resourceFhirEndpointModuleUpdate,
+		DeleteContext: resourceModuleConfigDelete,
+		CustomizeDiff: customdiff.All(
+			inheritProviderDefaults("node_id"),
+			resourceFhirEndpointModuleCustomizeDiff,
+		),
+		Schema: map[string]*schema.Schema{
+			"node_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+			},
+			"module_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"fhir_version": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "R4",
+				ValidateFunc: validation.StringInSlice(smilecdr.FhirVersions, false),
+			},
+			"port": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validation.IsPortNumber,
+			},
+			"base_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"cors_enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"cors_allowed_origins": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"storage_module_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"security_module_ids": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceModuleConfigImport,
+		},
+	}
+}
+
+// resourceFhirEndpointModuleCustomizeDiff checks the dependency chain and listener
+// port against the modules already on the node, so mistakes fail at plan time.
+func resourceFhirEndpointModuleCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	c, ok := m.(*smilecdr.Client)
+	if !ok || !d.NewValueKnown("node_id") {
+		return nil
+	}
+
+	nodeId := d.Get("node_id").(string)
+	moduleId := d.Get("module_id").(string)
+
+	node, err := c.GetModuleConfigs(nodeId)
+	if err != nil {
+		return fmt.Errorf("unable to list the modules on node %s: %s", nodeId, err)
+	}
+
+	if d.NewValueKnown("storage_module_id") {
+		ref := d.Get("storage_module_id").(string)
+		if err := checkModuleDependency(node, ref, smilecdr.PersistenceModuleTypePrefix); err != nil {
+			return fmt.Errorf("storage_module_id: %s", err)
+		}
+	}
+
+	if d.NewValueKnown("security_module_ids") {
+		for _, ref := range d.Get("security_module_ids").([]interface{}) {
+			if err := checkModuleDependency(node, ref.(string), smilecdr.SecurityInModuleTypePrefix); err != nil {
+				return fmt.Errorf("security_module_ids: %s", err)
+			}
+		}
+	}
+
+	if d.NewValueKnown("port") {
+		if err := checkListenerPort(node, moduleId, d.Get("port").(int)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkModuleDependency verifies that ref, when it names a module already on the
+// node, names one whose type starts with typePrefix. A module that is not on the
+// node yet may be created in the same apply, so it is left for the server to check.
+func checkModuleDependency(node smilecdr.NodeConfig, ref string, typePrefix string) error {
+	if ref == "" {
+		return nil
+	}
+	moduleId := moduleIdFromRef(ref)
+	module, ok := node.Module(moduleId)
+	if !ok {
+		return nil
+	}
+	if !strings.HasPrefix(module.ModuleType, typePrefix) {
+		return fmt.Errorf("module %s has type %s, expected a %s* module", moduleId, module.ModuleType, typePrefix)
+	}
+	return nil
+}
+
+// checkListenerPort verifies that no other active module on the node listens on port.
+func checkListenerPort(node smilecdr.NodeConfig, moduleId string, port int) error {
+	for _, module := range node.Modules {
+		if module.ModuleId != moduleId && module.ArchivedAt == "" && module.ListenerPort() == port {
+			return fmt.Errorf("port %d is already used by module %s on node %s", port, module.ModuleId, node.NodeId)
+		}
+	}
+	return nil
+}
+
+func resourceDataToFhirEndpointModule(d *schema.ResourceData) smilecdr.FhirEndpointModule {
+
+	corsAllowedOrigins := make([]string, 0)
+	for _, origin := range d.Get("cors_allowed_origins").([]interface{}) {
+		corsAllowedOrigins = append(corsAllowedOrigins, origin.(string))
+	}
+
+	securityModuleIds := make([]string, 0)
+	for _, ref := range d.Get("security_module_ids").([]interface{}) {
+		securityModuleIds = append(securityModuleIds, moduleIdFromRef(ref.(string)))
+	}
+
+	return smilecdr.FhirEndpointModule{
+		NodeId:             d.Get("node_id").(string),
+		ModuleId:           d.Get("module_id").(string),
+		FhirVersion:        d.Get("fhir_version").(string),
+		Port:               d.Get("port").(int),
+		BaseUrl:            d.Get("base_url").(string),
+		CorsEnabled:        d.Get("cors_enabled").(bool),
+		CorsAllowedOrigins: corsAllowedOrigins,
+		StorageModuleId:    moduleIdFromRef(d.Get("storage_module_id").(string)),
+		SecurityModuleIds:  securityModuleIds,
+	}
+}
+
+func resourceFhirEndpointModuleCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	module := resourceDataToFhirEndpointModule(d)
+
+	if err := upsertModuleConfig(c, module.ModuleConfig()); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(moduleConfigId(module.NodeId, module.ModuleId))
+
+	return resourceFhirEndpointModuleRead(ctx, d, m)
+}
+
+func resourceFhirEndpointModuleRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	nodeId := d.Get("node_id").(string)
+	moduleId := d.Get("module_id").(string)
+
+	config, err := c.GetModuleConfig(nodeId, moduleId)
+	if smilecdr.IsNotFound(err) || (err == nil && config.ArchivedAt != "") {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	module, err := smilecdr.FhirEndpointModuleFromConfig(config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("node_id", nodeId)
+	d.Set("module_id", module.ModuleId)
+	d.Set("fhir_version", module.FhirVersion)
+	d.Set("port", module.Port)
+	d.Set("base_url", module.BaseUrl)
+	d.Set("cors_enabled", module.CorsEnabled)
+	d.Set("cors_allowed_origins", module.CorsAllowedOrigins)
+
+	// keep "node/module" references as written while they still point at the same module
+	if moduleIdFromRef(d.Get("storage_module_id").(string)) != module.StorageModuleId {
+		d.Set("storage_module_id", module.StorageModuleId)
+	}
+	refs := d.Get("security_module_ids").([]interface{})
+	sameSecurity := len(refs) == len(module.SecurityModuleIds)
+	for i := 0; sameSecurity && i < len(refs); i++ {
+		sameSecurity = moduleIdFromRef(refs[i].(string)) == module.SecurityModuleIds[i]
+	}
+	if !sameSecurity {
+		d.Set("security_module_ids", module.SecurityModuleIds)
+	}
+
+	return diags
+}
+
+func resourceFhirEndpointModuleUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	module := resourceDataToFhirEndpointModule(d)
+
+	if err := mergeModuleConfig(c, module.ModuleConfig()); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceFhirEndpointModuleRead(ctx, d, m)
+}
@@ -0,0 +1,24 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func Test_checkModuleDependency(t *testing.T) {
+	node := smilecdr.NodeConfig{NodeId: "Master", Modules: []smilecdr.ModuleConfig{
+		{ModuleId: "persistence", ModuleType: "PERSISTENCE_R4"},
+		{ModuleId: "local_security", ModuleType: "SECURITY_IN_LOCAL"},
+	}}
+
+	if err := checkModuleDependency(node, "persistence_new", smilecdr.PersistenceModuleTypePrefix); err != nil {
+		t.Fatalf("a module created in the same apply should pass, got %s", err)
+	}
+	if err := checkModuleDependency(node, "Master/persistence", smilecdr.PersistenceModuleTypePrefix); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := checkModuleDependency(node, "local_security", smilecdr.PersistenceModuleTypePrefix); err == nil {
+		t.Fatal("expected an error for a module of the wrong type")
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/provider/util"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceFhirResource() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceFhirResourceCreate,
+		ReadContext:   resourceFhirResourceRead,
+		UpdateContext: resourceFhirResourceUpdate,
+		DeleteContext: resourceFhirResourceDelete,
+		CustomizeDiff: resourceFhirResourceCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"resource_type": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"resource_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"partition_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"body": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: suppressFhirResourceDiff,
+			},
+			"version_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"last_updated": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceFhirResourceImport,
+		},
+	}
+}
+
+// suppressFhirResourceDiff compares FHIR resources semantically, ignoring member
+// order, whitespace and the server managed id and meta.
+func suppressFhirResourceDiff(k, old, new string, d *schema.ResourceData) bool {
+	oldNormalized, err := util.NormalizeFhirResource(old)
+	if err != nil {
+		return false
+	}
+	newNormalized, err := util.NormalizeFhirResource(new)
+	if err != nil {
+		return false
+	}
+	return oldNormalized == newNormalized
+}
+
+func resourceFhirResourceCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("body") || !d.NewValueKnown("resource_type") {
+		return nil
+	}
+	resourceType, err := util.FhirResourceType(d.Get("body").(string))
+	if err != nil {
+		return fmt.Errorf("body: %s", err)
+	}
+	if expected := d.Get("resource_type").(string); resourceType != expected {
+		return fmt.Errorf("body has resourceType %s, expected %s", resourceType, expected)
+	}
+	return nil
+}
+
+// fhirResourceId builds the "[partition/]Type/id" resource ID.
+func fhirResourceId(partitionName string, resourceType string, id string) string {
+	if partitionName == "" {
+		return resourceType + "/" + id
+	}
+	return partitionName + "/" + resourceType + "/" + id
+}
+
+func resourceFhirResourceImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.Split(d.Id(), "/")
+	switch len(parts) {
+	case 2:
+		d.Set("resource_type", parts[0])
+		d.Set("resource_id", parts[1])
+	case 3:
+		d.Set("partition_name", parts[0])
+		d.Set("resource_type", parts[1])
+		d.Set("resource_id", parts[2])
+	default:
+		return nil, fmt.Errorf("unexpected FHIR resource ID %q, expected [partition/]Type/id", d.Id())
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// fhirClientForResource returns the FHIR client, routed to the resource's partition if it has one.
+func fhirClientForResource(d *schema.ResourceData, m interface{}) (*smilecdr.FhirClient, error) {
+	c, err := providerClient(m)
+	if err != nil {
+		return nil, err
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return nil, err
+	}
+	return fhir.ForPartition(d.Get("partition_name").(string)), nil
+}
+
+func resourceFhirResourceCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	fhir, err := fhirClientForResource(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	resourceType := d.Get("resource_type").(string)
+	resourceId := d.Get("resource_id").(string)
+	body := d.Get("body").(string)
+
+	var response []byte
+	if resourceId == "" {
+		response, err = fhir.Create(resourceType, []byte(body))
+	} else {
+		// client assigned IDs are created with a PUT
+		var resource []byte
+		if resource, err = util.FhirResourceWithId(body, resourceId); err == nil {
+			response, err = fhir.Update(resourceType, resourceId, resource, "")
+		}
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	meta, err := smilecdr.ParseResourceMeta(response)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fhirResourceId(d.Get("partition_name").(string), resourceType, meta.Id))
+	d.Set("resource_id", meta.Id)
+
+	return resourceFhirResourceRead(ctx, d, m)
+}
+
+func resourceFhirResourceRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	fhir, err := fhirClientForResource(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	body, err := fhir.Read(d.Get("resource_type").(string), d.Get("resource_id").(string))
+	if smilecdr.IsNotFound(err) || smilecdr.IsGone(err) {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	meta, err := smilecdr.ParseResourceMeta(body)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("body", string(body))
+	d.Set("version_id", meta.Meta.VersionId)
+	d.Set("last_updated", meta.Meta.LastUpdated)
+
+	return diags
+}
+
+func resourceFhirResourceUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	fhir, err := fhirClientForResource(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	resourceId := d.Get("resource_id").(string)
+	resource, err := util.FhirResourceWithId(d.Get("body").(string), resourceId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// only overwrite the version we last read, a concurrent change fails with 412
+	_, err = fhir.Update(d.Get("resource_type").(string), resourceId, resource, d.Get("version_id").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceFhirResourceRead(ctx, d, m)
+}
+
+func resourceFhirResourceDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	fhir, err := fhirClientForResource(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := fhir.Delete(d.Get("resource_type").(string), d.Get("resource_id").(string)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
@@ -0,0 +1,204 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceFhirStorageModule() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceFhirStorageModuleCreate,
+		ReadContext:   resourceFhirStorageModuleRead,
+		UpdateContext: resourceFhirStorageModuleUpdate,
+		DeleteContext: resourceModuleConfigDelete,
+		CustomizeDiff: inheritProviderDefaults("node_id"),
+		Schema: map[string]*schema.Schema{
+			"node_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+			},
+			"module_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"fhir_version": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "R4",
+				ValidateFunc: validation.StringInSlice(smilecdr.FhirVersions, false),
+			},
+			"db_driver": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(smilecdr.FhirStorageDbDrivers, false),
+			},
+			"db_url": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+			},
+			"db_username": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"db_password": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+			"db_max_connections": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      100,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"partitioning_mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "NONE",
+				ValidateFunc: validation.StringInSlice(smilecdr.FhirStoragePartitioning, false),
+			},
+			"search_cache_enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"search_cache_millis": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      60000,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"reindex_enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"expunge_enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"delete_expunge_enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceModuleConfigImport,
+		},
+	}
+}
+
+func resourceDataToFhirStorageModule(d *schema.ResourceData) smilecdr.FhirStorageModule {
+	return smilecdr.FhirStorageModule{
+		NodeId:               d.Get("node_id").(string),
+		ModuleId:             d.Get("module_id").(string),
+		FhirVersion:          d.Get("fhir_version").(string),
+		DbDriver:             d.Get("db_driver").(string),
+		DbUrl:                d.Get("db_url").(string),
+		DbUsername:           d.Get("db_username").(string),
+		DbPassword:           d.Get("db_password").(string),
+		DbMaxConnections:     d.Get("db_max_connections").(int),
+		PartitioningMode:     d.Get("partitioning_mode").(string),
+		SearchCacheEnabled:   d.Get("search_cache_enabled").(bool),
+		SearchCacheMillis:    d.Get("search_cache_millis").(int),
+		ReindexEnabled:       d.Get("reindex_enabled").(bool),
+		ExpungeEnabled:       d.Get("expunge_enabled").(bool),
+		DeleteExpungeEnabled: d.Get("delete_expunge_enabled").(bool),
+	}
+}
+
+func resourceFhirStorageModuleCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	module := resourceDataToFhirStorageModule(d)
+
+	if err := upsertModuleConfig(c, module.ModuleConfig()); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(moduleConfigId(module.NodeId, module.ModuleId))
+
+	return resourceFhirStorageModuleRead(ctx, d, m)
+}
+
+func resourceFhirStorageModuleRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	nodeId := d.Get("node_id").(string)
+	moduleId := d.Get("module_id").(string)
+
+	config, err := c.GetModuleConfig(nodeId, moduleId)
+	if smilecdr.IsNotFound(err) || (err == nil && config.ArchivedAt != "") {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	module, err := smilecdr.FhirStorageModuleFromConfig(config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("node_id", nodeId)
+	d.Set("module_id", module.ModuleId)
+	d.Set("fhir_version", module.FhirVersion)
+	d.Set("db_driver", module.DbDriver)
+	d.Set("db_url", module.DbUrl)
+	d.Set("db_username", module.DbUsername)
+	// db_password keeps the configured value, the password in the module config is
+	// not compared so a masked or re-encoded value does not show as drift
+	d.Set("db_max_connections", module.DbMaxConnections)
+	d.Set("partitioning_mode", module.PartitioningMode)
+	d.Set("search_cache_enabled", module.SearchCacheEnabled)
+	d.Set("search_cache_millis", module.SearchCacheMillis)
+	d.Set("reindex_enabled", module.ReindexEnabled)
+	d.Set("expunge_enabled", module.ExpungeEnabled)
+	d.Set("delete_expunge_enabled", module.DeleteExpungeEnabled)
+
+	return diags
+}
+
+func resourceFhirStorageModuleUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	module := resourceDataToFhirStorageModule(d)
+
+	// a cleared db_password removes the password from the module
+	previous := module
+	oldPassword, _ := d.GetChange("db_password")
+	previous.DbPassword = oldPassword.(string)
+
+	if err := mergeModuleConfig(c, module.ModuleConfig(), module.ClearedOptions(previous)...); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceFhirStorageModuleRead(ctx, d, m)
+}
@@ -0,0 +1,192 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/structure"
+	"github.com/zed-werks/terraform-smilecdr/provider/util"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceMdmRules() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceMdmRulesCreate,
+		ReadContext:   resourceMdmRulesRead,
+		UpdateContext: resourceMdmRulesUpdate,
+		DeleteContext: resourceMdmRulesDelete,
+		CustomizeDiff: inheritProviderDefaults("node_id"),
+		Schema: map[string]*schema.Schema{
+			"node_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+			},
+			"module_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"rules_json": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateFunc:     util.ValidateMdmRules,
+				DiffSuppressFunc: structure.SuppressJsonDiff,
+			},
+			"clear_and_resubmit": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceModuleConfigImport,
+		},
+	}
+}
+
+func setMdmRules(c *smilecdr.Client, d *schema.ResourceData) error {
+	config := smilecdr.ModuleConfig{
+		NodeId:   d.Get("node_id").(string),
+		ModuleId: d.Get("module_id").(string),
+		Options:  []smilecdr.ModuleOption{{Key: smilecdr.MdmRulesJsonOption, Value: d.Get("rules_json").(string)}},
+	}
+	return mergeModuleConfig(c, config)
+}
+
+// clearAndResubmitMdm rebuilds the MDM links under the new rules. The module only
+// loads its rules when it starts, so it is restarted first. Then the existing golden
+// resources and links are cleared and every resource is matched again.
+func clearAndResubmitMdm(ctx context.Context, c *smilecdr.Client, d *schema.ResourceData, timeout time.Duration) error {
+	nodeId := d.Get("node_id").(string)
+	moduleId := d.Get("module_id").(string)
+	deadline := time.Now().Add(timeout)
+
+	rules, err := util.ParseMdmRules(d.Get("rules_json").(string))
+	if err != nil {
+		return err
+	}
+
+	before, err := c.GetModuleStatus(nodeId, moduleId)
+	if err != nil {
+		return err
+	}
+	if err := c.RestartModule(nodeId, moduleId); err != nil {
+		return err
+	}
+	if err := waitForModuleTransition(ctx, c, nodeId, moduleId, before, time.Until(deadline)); err != nil {
+		return err
+	}
+	if err := waitForModuleStatus(ctx, c, nodeId, moduleId, smilecdr.ModuleStatusStarted, time.Until(deadline)); err != nil {
+		return err
+	}
+
+	fhir, err := c.Fhir()
+	if err != nil {
+		return err
+	}
+
+	jobId, err := fhir.MdmClear(rules.MdmTypes)
+	if err != nil {
+		return err
+	}
+	if jobId != "" {
+		if err := waitForBatchJob(ctx, c, jobId, time.Until(deadline)); err != nil {
+			return err
+		}
+	}
+
+	jobId, err = fhir.MdmSubmit(rules.MdmTypes)
+	if err != nil {
+		return err
+	}
+	if jobId != "" {
+		return waitForBatchJob(ctx, c, jobId, time.Until(deadline))
+	}
+	return nil
+}
+
+func resourceMdmRulesCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	if err := setMdmRules(c, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(moduleConfigId(d.Get("node_id").(string), d.Get("module_id").(string)))
+
+	if d.Get("clear_and_resubmit").(bool) {
+		if err := clearAndResubmitMdm(ctx, c, d, d.Timeout(schema.TimeoutCreate)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceMdmRulesRead(ctx, d, m)
+}
+
+func resourceMdmRulesRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	config, err := c.GetModuleConfig(d.Get("node_id").(string), d.Get("module_id").(string))
+	if smilecdr.IsNotFound(err) || (err == nil && config.ArchivedAt != "") {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("rules_json", config.Option(smilecdr.MdmRulesJsonOption))
+
+	return diags
+}
+
+func resourceMdmRulesUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	if d.HasChange("rules_json") {
+		if err := setMdmRules(c, d); err != nil {
+			return diag.FromErr(err)
+		}
+
+		if d.Get("clear_and_resubmit").(bool) {
+			if err := clearAndResubmitMdm(ctx, c, d, d.Timeout(schema.TimeoutUpdate)); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+
+	return resourceMdmRulesRead(ctx, d, m)
+}
+
+func resourceMdmRulesDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	// the MDM module cannot run without rules, they stay on the module
+	d.SetId("")
+
+	return diags
+}
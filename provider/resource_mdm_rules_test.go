@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func Test_clearAndResubmitMdmRestartsFirst(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	restarted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/restart"):
+			calls = append(calls, "restart")
+			restarted = true
+			w.Write([]byte(`{}`))
+		case strings.HasSuffix(r.URL.Path, "$mdm-clear"):
+			calls = append(calls, "clear")
+			w.WriteHeader(http.StatusInternalServerError)
+		case restarted:
+			w.Write([]byte(`{"status":"STARTED","startTime":"2024-01-01T01:00:00Z"}`))
+		default:
+			w.Write([]byte(`{"status":"STARTED","startTime":"2024-01-01T00:00:00Z"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := smilecdr.NewClient(server.URL, "admin", "password")
+	c.SetFhirClient(smilecdr.NewFhirClient(server.URL, "admin", "password"))
+	d := schema.TestResourceDataRaw(t, resourceMdmRules().Schema, map[string]interface{}{
+		"node_id":    "Master",
+		"module_id":  "mdm",
+		"rules_json": `{"mdmTypes": ["Patient"]}`,
+	})
+
+	if err := clearAndResubmitMdm(context.Background(), c, d, 30*time.Second); err == nil {
+		t.Fatal("expected the failed clear to be reported")
+	}
+	if strings.Join(calls, ",") != "restart,clear" {
+		t.Fatalf("expected the module to restart before the clear, got %v", calls)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceModuleConfig() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceModuleConfigCreate,
+		ReadContext:   resourceModuleConfigRead,
+		UpdateContext: resourceModuleConfigUpdate,
+		DeleteContext: resourceModuleConfigDelete,
+		CustomizeDiff: inheritProviderDefaults("node_id"),
+		Schema: map[string]*schema.Schema{
+			"node_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+			},
+			"module_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"module_type": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"options": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			// options on the server that are not in options, reported on refresh so
+			// changes made outside Terraform are visible, but never corrected
+			"unmanaged_options": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"dependencies": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"module_id": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceModuleConfigImport,
+		},
+	}
+}
+
+// moduleConfigId builds the "node/module" resource ID shared by all module resources.
+func moduleConfigId(nodeId string, moduleId string) string {
+	return nodeId + "/" + moduleId
+}
+
+func parseModuleConfigId(id string) (string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unexpected module ID %q, expected node_id/module_id", id)
+	}
+	return parts[0], parts[1], nil
+}
+
+// moduleIdFromRef accepts either a bare module ID or the "node/module" ID of another
+// module resource, so dependencies can reference modules created in the same plan.
+func moduleIdFromRef(ref string) string {
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		return ref[i+1:]
+	}
+	return ref
+}
+
+func resourceModuleConfigImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	nodeId, moduleId, err := parseModuleConfigId(d.Id())
+	if err != nil {
+		return nil, err
+	}
+	d.Set("node_id", nodeId)
+	d.Set("module_id", moduleId)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// upsertModuleConfig creates the module, or reinstates and reconfigures it when an
+// archived module with the same ID already exists on the node.
+func upsertModuleConfig(c *smilecdr.Client, config smilecdr.ModuleConfig) error {
+	existing, err := c.GetModuleConfig(config.NodeId, config.ModuleId)
+	if err != nil && !smilecdr.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil {
+		if existing.ArchivedAt == "" {
+			return fmt.Errorf("module %s already exists on node %s, import it instead", config.ModuleId, config.NodeId)
+		}
+		if err := c.ReinstateModuleConfig(config.NodeId, config.ModuleId); err != nil {
+			return err
+		}
+		_, err = c.PutModuleConfig(config)
+		return err
+	}
+
+	_, err = c.PostModuleConfig(config)
+	return err
+}
+
+// mergeModuleConfig updates the options (and dependencies, when non-nil) set in
+// config, removes the removedKeys options and leaves every other option on the
+// module untouched. Module resources use it so that options they do not manage
+// survive an update.
+func mergeModuleConfig(c *smilecdr.Client, config smilecdr.ModuleConfig, removedKeys ...string) error {
+	existing, err := c.GetModuleConfig(config.NodeId, config.ModuleId)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range removedKeys {
+		existing.DeleteOption(key)
+	}
+	for _, option := range config.Options {
+		existing.SetOption(option.Key, option.Value)
+	}
+	if config.Dependencies != nil {
+		existing.Dependencies = config.Dependencies
+	}
+
+	_, err = c.PutModuleConfig(existing)
+	return err
+}
+
+func resourceDataToModuleDependencies(d *schema.ResourceData) []smilecdr.ModuleDependency {
+	dependencies := make([]smilecdr.ModuleDependency, 0)
+	for _, dep := range d.Get("dependencies").(*schema.Set).List() {
+		dependency := dep.(map[string]interface{})
+		dependencies = append(dependencies, smilecdr.ModuleDependency{
+			ModuleId: dependency["module_id"].(string),
+			Type:     dependency["type"].(string),
+		})
+	}
+	return dependencies
+}
+
+func flattenModuleDependencies(dependencies []smilecdr.ModuleDependency) []interface{} {
+	deps := make([]interface{}, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		deps = append(deps, map[string]interface{}{
+			"module_id": dependency.ModuleId,
+			"type":      dependency.Type,
+		})
+	}
+	return deps
+}
+
+func resourceDataToModuleConfig(d *schema.ResourceData) *smilecdr.ModuleConfig {
+
+	options := make([]smilecdr.ModuleOption, 0)
+	for key, value := range d.Get("options").(map[string]interface{}) {
+		options = append(options, smilecdr.ModuleOption{Key: key, Value: value.(string)})
+	}
+
+	return &smilecdr.ModuleConfig{
+		NodeId:       d.Get("node_id").(string),
+		ModuleId:     d.Get("module_id").(string),
+		ModuleType:   d.Get("module_type").(string),
+		Options:      options,
+		Dependencies: resourceDataToModuleDependencies(d),
+	}
+}
+
+func resourceModuleConfigCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	config := resourceDataToModuleConfig(d)
+
+	if err := upsertModuleConfig(c, *config); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(moduleConfigId(config.NodeId, config.ModuleId))
+
+	return resourceModuleConfigRead(ctx, d, m)
+}
+
+func resourceModuleConfigRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	nodeId := d.Get("node_id").(string)
+	moduleId := d.Get("module_id").(string)
+
+	config, err := c.GetModuleConfig(nodeId, moduleId)
+	if smilecdr.IsNotFound(err) {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if config.ArchivedAt != "" {
+		d.SetId("")
+		return diags
+	}
+
+	// Only options already under management are tracked in options, so server-side
+	// defaults do not show up as diffs. Every other option is recorded in
+	// unmanaged_options, which reports changes made outside Terraform on refresh. An
+	// import has nothing under management and takes them all.
+	importing := d.Get("module_type").(string) == ""
+	managed := d.Get("options").(map[string]interface{})
+	options := make(map[string]interface{})
+	unmanaged := make(map[string]interface{})
+	for key, value := range config.OptionsMap() {
+		if _, ok := managed[key]; ok || importing {
+			options[key] = value
+		} else {
+			unmanaged[key] = value
+		}
+	}
+
+	d.Set("node_id", nodeId)
+	d.Set("module_id", config.ModuleId)
+	d.Set("module_type", config.ModuleType)
+	d.Set("options", options)
+	d.Set("unmanaged_options", unmanaged)
+	d.Set("dependencies", flattenModuleDependencies(config.Dependencies))
+
+	return diags
+}
+
+func resourceModuleConfigUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	config := resourceDataToModuleConfig(d)
+
+	// options that left the configuration are removed, unmanaged ones stay
+	var removed []string
+	oldOptions, newOptions := d.GetChange("options")
+	for key := range oldOptions.(map[string]interface{}) {
+		if _, ok := newOptions.(map[string]interface{})[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+
+	if err := mergeModuleConfig(c, *config, removed...); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceModuleConfigRead(ctx, d, m)
+}
+
+func resourceModuleConfigDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	err := c.ArchiveModuleConfig(d.Get("node_id").(string), d.Get("module_id").(string))
+	if err != nil && !smilecdr.IsNotFound(err) {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
@@ -0,0 +1,237 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceModuleState() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceModuleStateCreate,
+		ReadContext:   resourceModuleStateRead,
+		UpdateContext: resourceModuleStateUpdate,
+		DeleteContext: resourceModuleStateDelete,
+		CustomizeDiff: inheritProviderDefaults("node_id"),
+		Schema: map[string]*schema.Schema{
+			"node_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+			},
+			"module_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"desired_state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      smilecdr.ModuleStatusStarted,
+				ValidateFunc: validation.StringInSlice([]string{smilecdr.ModuleStatusStarted, smilecdr.ModuleStatusStopped}, false),
+			},
+			"restart_triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceModuleConfigImport,
+		},
+	}
+}
+
+// waitForModuleStatus polls the module status until it reaches target. Only the
+// STARTING and STOPPING transitions are waited out, any other status fails the
+// wait. A FAILED module, or a timeout, is reported together with the module's
+// failure message.
+func waitForModuleStatus(ctx context.Context, c *smilecdr.Client, nodeId string, moduleId string, target string, timeout time.Duration) error {
+	var last smilecdr.ModuleStatus
+
+	stateConf := &retry.StateChangeConf{
+		Pending: []string{
+			smilecdr.ModuleStatusStarting,
+			smilecdr.ModuleStatusStopping,
+		},
+		Target: []string{target},
+		Refresh: func() (interface{}, string, error) {
+			status, err := c.GetModuleStatus(nodeId, moduleId)
+			if err != nil {
+				return nil, "", err
+			}
+			last = status
+			if status.Status == smilecdr.ModuleStatusFailed {
+				return status, status.Status, fmt.Errorf("module %s on node %s failed: %s", moduleId, nodeId, status.FailureMessage)
+			}
+			return status, status.Status, nil
+		},
+		Timeout:    timeout,
+		Delay:      2 * time.Second,
+		MinTimeout: 2 * time.Second,
+	}
+
+	_, err := stateConf.WaitForStateContext(ctx)
+	if err != nil && last.FailureMessage != "" && last.Status != smilecdr.ModuleStatusFailed {
+		return fmt.Errorf("%s (last status %s: %s)", err, last.Status, last.FailureMessage)
+	}
+	return err
+}
+
+// waitForModuleTransition polls the module until it has acted on a start, stop or
+// restart command: its status differs from before, or it reports a new start time
+// because it restarted between two polls.
+func waitForModuleTransition(ctx context.Context, c *smilecdr.Client, nodeId string, moduleId string, before smilecdr.ModuleStatus, timeout time.Duration) error {
+	stateConf := &retry.StateChangeConf{
+		Pending: []string{"waiting"},
+		Target:  []string{"changed"},
+		Refresh: func() (interface{}, string, error) {
+			status, err := c.GetModuleStatus(nodeId, moduleId)
+			if err != nil {
+				return nil, "", err
+			}
+			if status.Status != before.Status || status.StartTime != before.StartTime {
+				return status, "changed", nil
+			}
+			return status, "waiting", nil
+		},
+		Timeout:      timeout,
+		PollInterval: time.Second,
+	}
+
+	if _, err := stateConf.WaitForStateContext(ctx); err != nil {
+		return fmt.Errorf("module %s on node %s did not act on the command, still %s: %s", moduleId, nodeId, before.Status, err)
+	}
+	return nil
+}
+
+// applyModuleState moves the module towards desired_state, restarting it when
+// restart is set and the module should be running.
+func applyModuleState(ctx context.Context, d *schema.ResourceData, c *smilecdr.Client, restart bool, timeout time.Duration) error {
+	nodeId := d.Get("node_id").(string)
+	moduleId := d.Get("module_id").(string)
+	desired := d.Get("desired_state").(string)
+	deadline := time.Now().Add(timeout)
+
+	current, err := c.GetModuleStatus(nodeId, moduleId)
+	if err != nil {
+		return err
+	}
+
+	commanded := true
+	switch {
+	case desired == smilecdr.ModuleStatusStopped && current.Status != smilecdr.ModuleStatusStopped:
+		err = c.StopModule(nodeId, moduleId)
+	case desired == smilecdr.ModuleStatusStarted && current.Status != smilecdr.ModuleStatusStarted:
+		err = c.StartModule(nodeId, moduleId)
+	case desired == smilecdr.ModuleStatusStarted && restart:
+		err = c.RestartModule(nodeId, moduleId)
+	default:
+		commanded = false
+	}
+	if err != nil {
+		return err
+	}
+
+	// right after the command the module still reports its old status, which may
+	// already be the target
+	if commanded && current.Status != smilecdr.ModuleStatusStarting && current.Status != smilecdr.ModuleStatusStopping {
+		if err := waitForModuleTransition(ctx, c, nodeId, moduleId, current, time.Until(deadline)); err != nil {
+			return err
+		}
+	}
+
+	return waitForModuleStatus(ctx, c, nodeId, moduleId, desired, time.Until(deadline))
+}
+
+func resourceModuleStateCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	if err := applyModuleState(ctx, d, c, false, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(moduleConfigId(d.Get("node_id").(string), d.Get("module_id").(string)))
+
+	return resourceModuleStateRead(ctx, d, m)
+}
+
+func resourceModuleStateRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	nodeId := d.Get("node_id").(string)
+	moduleId := d.Get("module_id").(string)
+
+	status, err := c.GetModuleStatus(nodeId, moduleId)
+	if smilecdr.IsNotFound(err) {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("node_id", nodeId)
+	d.Set("module_id", moduleId)
+	d.Set("status", status.Status)
+
+	// any status other than the desired one is drift, so a FAILED or stuck module is
+	// started again on the next apply
+	d.Set("desired_state", status.Status)
+
+	return diags
+}
+
+func resourceModuleStateUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	restart := d.HasChange("restart_triggers")
+
+	if err := applyModuleState(ctx, d, c, restart, d.Timeout(schema.TimeoutUpdate)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceModuleStateRead(ctx, d, m)
+}
+
+func resourceModuleStateDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	// the module is left in whatever state it is in, only Terraform stops managing it
+	d.SetId("")
+
+	return diags
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func Test_applyModuleStateWaitsForRestart(t *testing.T) {
+	var restarted, polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/restart"):
+			atomic.StoreInt32(&restarted, 1)
+			w.Write([]byte(`{}`))
+		case atomic.LoadInt32(&restarted) == 0:
+			w.Write([]byte(`{"status":"STARTED","startTime":"2024-01-01T00:00:00Z"}`))
+		case atomic.AddInt32(&polls, 1) == 1:
+			// the restart has not been picked up yet
+			w.Write([]byte(`{"status":"STARTED","startTime":"2024-01-01T00:00:00Z"}`))
+		default:
+			w.Write([]byte(`{"status":"FAILED","failureMessage":"port in use"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := smilecdr.NewClient(server.URL, "admin", "password")
+	d := schema.TestResourceDataRaw(t, resourceModuleState().Schema, map[string]interface{}{
+		"node_id":       "Master",
+		"module_id":     "fhir_endpoint",
+		"desired_state": smilecdr.ModuleStatusStarted,
+	})
+
+	err := applyModuleState(context.Background(), d, c, true, 30*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "port in use") {
+		t.Fatalf("expected the failed restart to be reported, got %v", err)
+	}
+}
+
+func Test_resourceModuleStateReadReportsFailedModule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"FAILED","failureMessage":"port in use"}`))
+	}))
+	defer server.Close()
+
+	c := smilecdr.NewClient(server.URL, "admin", "password")
+	d := schema.TestResourceDataRaw(t, resourceModuleState().Schema, map[string]interface{}{
+		"node_id":       "Master",
+		"module_id":     "fhir_endpoint",
+		"desired_state": smilecdr.ModuleStatusStarted,
+	})
+	d.SetId("Master/fhir_endpoint")
+
+	if diags := resourceModuleStateRead(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if state := d.Get("desired_state").(string); state != smilecdr.ModuleStatusFailed {
+		t.Fatalf("expected the FAILED module to show as drift, got %s", state)
+	}
+}
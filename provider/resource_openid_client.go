@@ -169,6 +169,7 @@ func resourceOpenIdClient() *schema.Resource {
 		ReadContext:   resourceOpenIdClientRead,
 		UpdateContext: resourceOpenIdClientUpdate,
 		DeleteContext: resourceOpenIdClientDelete,
+		CustomizeDiff: inheritProviderDefaults("node_id", "module_id"),
 		Schema: map[string]*schema.Schema{
 			"pid": {
 				Type:     schema.TypeInt,
@@ -179,13 +180,13 @@ func resourceOpenIdClient() *schema.Resource {
 				Type:     schema.TypeString,
 				Required: false,
 				Optional: true,
-				Default:  "Master",
+				Computed: true,
 			},
 			"module_id": {
 				Type:     schema.TypeString,
 				Required: false,
 				Optional: true,
-				Default:  "smart_auth",
+				Computed: true,
 			},
 			"access_token_validity_seconds": {
 				Type:     schema.TypeInt,
@@ -357,9 +358,18 @@ func resourceOpenIdClient() *schema.Resource {
 				Default:  false,
 			},
 			"public_jwks_uri": {
-				Type:     schema.TypeString,
-				Required: false,
-				Optional: true,
+				Type:          schema.TypeString,
+				Required:      false,
+				Optional:      true,
+				ConflictsWith: []string{"public_jwks"},
+			},
+			"public_jwks": {
+				Type:          schema.TypeString,
+				Required:      false,
+				Optional:      true,
+				ValidateFunc:  util.ValidatePublicJwks,
+				StateFunc:     func(v interface{}) string { return util.NormalizeJwks(v.(string)) },
+				ConflictsWith: []string{"public_jwks_uri"},
 			},
 			"archived_at": {
 				Type:         schema.TypeString,
@@ -457,6 +467,7 @@ func resourceDataToOpenIdClient(d *schema.ResourceData) (*smilecdr.OpenIdClient,
 		Permissions:                 permissions,
 		AttestationAccepted:         d.Get("attestation_accepted").(bool),
 		PublicJwksUri:               d.Get("public_jwks_uri").(string),
+		PublicJwks:                  d.Get("public_jwks").(string),
 		ArchivedAt:                  d.Get("archived_at").(string),
 		CreatedByAppSphere:          d.Get("created_by_app_sphere").(bool),
 	}
@@ -467,7 +478,10 @@ func resourceDataToOpenIdClient(d *schema.ResourceData) (*smilecdr.OpenIdClient,
 
 func resourceOpenIdClientCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 
-	c := m.(*smilecdr.Client)
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
 
 	client, mErr := resourceDataToOpenIdClient(d)
 	if mErr != nil {
@@ -488,9 +502,10 @@ func resourceOpenIdClientCreate(ctx context.Context, d *schema.ResourceData, m i
 
 func resourceOpenIdClientRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 
-	var diags diag.Diagnostics
-
-	c := m.(*smilecdr.Client)
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
 
 	client_id := d.Get("client_id").(string)
 	nodeId := d.Get("node_id").(string)
@@ -526,6 +541,7 @@ func resourceOpenIdClientRead(ctx context.Context, d *schema.ResourceData, m int
 	d.Set("permissions", openIdClient.Permissions)
 	d.Set("attestation_accepted", openIdClient.AttestationAccepted)
 	d.Set("public_jwks_uri", openIdClient.PublicJwksUri)
+	d.Set("public_jwks", util.NormalizeJwks(openIdClient.PublicJwks))
 	d.Set("archived_at", openIdClient.ArchivedAt)
 	d.Set("created_by_app_sphere", openIdClient.CreatedByAppSphere)
 	return diags
@@ -534,7 +550,10 @@ func resourceOpenIdClientRead(ctx context.Context, d *schema.ResourceData, m int
 
 func resourceOpenIdClientUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 
-	c := m.(*smilecdr.Client)
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
 
 	client, mErr := resourceDataToOpenIdClient(d)
 	if mErr != nil {
@@ -0,0 +1,276 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/provider/util"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceOpenIdServer() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpenIdServerCreate,
+		ReadContext:   resourceOpenIdServerRead,
+		UpdateContext: resourceOpenIdServerUpdate,
+		DeleteContext: resourceOpenIdServerDelete,
+		CustomizeDiff: customdiff.All(
+			inheritProviderDefaults("node_id", "module_id"),
+			func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+				return customizeScriptDiff(d, "token_mapping_script", "token_mapping_script_file", "token_mapping_script_hash")
+			},
+		),
+		Schema: map[string]*schema.Schema{
+			"pid": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"node_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+			},
+			"module_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"issuer": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"jwks_url": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ValidateFunc:  validation.IsURLWithHTTPorHTTPS,
+				ConflictsWith: []string{"validation_jwks"},
+			},
+			"validation_jwks": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: structure.SuppressJsonDiff,
+				ConflictsWith:    []string{"jwks_url"},
+			},
+			"federation_client_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"federation_client_secret": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+			"authorization_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"token_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"user_info_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"request_scopes": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"token_mapping_script": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ValidateFunc:  util.ValidateJavaScript,
+				ConflictsWith: []string{"token_mapping_script_file"},
+			},
+			"token_mapping_script_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"token_mapping_script"},
+			},
+			"token_mapping_script_hash": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceOpenIdServerImport,
+		},
+	}
+}
+
+func openIdServerId(nodeId string, moduleId string, pid int) string {
+	return fmt.Sprintf("%s/%s/%d", nodeId, moduleId, pid)
+}
+
+func resourceOpenIdServerImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.Split(d.Id(), "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("unexpected OpenID server ID %q, expected node_id/module_id/pid", d.Id())
+	}
+	pid, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected OpenID server ID %q, pid must be a number", d.Id())
+	}
+	d.Set("node_id", parts[0])
+	d.Set("module_id", parts[1])
+	d.Set("pid", pid)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceDataToOpenIdServer(d *schema.ResourceData) (*smilecdr.OpenIdServer, error) {
+
+	tokenMappingScript, err := scriptFromResourceData(d, "token_mapping_script", "token_mapping_script_file")
+	if err != nil {
+		return nil, err
+	}
+
+	requestScopes := make([]string, 0)
+	for _, scope := range d.Get("request_scopes").(*schema.Set).List() {
+		requestScopes = append(requestScopes, scope.(string))
+	}
+	sort.Strings(requestScopes)
+
+	return &smilecdr.OpenIdServer{
+		Pid:                 d.Get("pid").(int),
+		NodeId:              d.Get("node_id").(string),
+		ModuleId:            d.Get("module_id").(string),
+		Name:                d.Get("name").(string),
+		Issuer:              d.Get("issuer").(string),
+		ValidationJwkText:   d.Get("validation_jwks").(string),
+		FedJwkSetUrl:        d.Get("jwks_url").(string),
+		FedClientId:         d.Get("federation_client_id").(string),
+		FedClientSecret:     d.Get("federation_client_secret").(string),
+		FedAuthorizationUrl: d.Get("authorization_url").(string),
+		FedTokenUrl:         d.Get("token_url").(string),
+		FedUserInfoUrl:      d.Get("user_info_url").(string),
+		FedRequestScopes:    strings.Join(requestScopes, " "),
+		AuthScriptText:      tokenMappingScript,
+	}, nil
+}
+
+func resourceOpenIdServerCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	server, mErr := resourceDataToOpenIdServer(d)
+	if mErr != nil {
+		return diag.FromErr(mErr)
+	}
+
+	o, err := c.PostOpenIdServer(*server)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(openIdServerId(server.NodeId, server.ModuleId, o.Pid))
+	d.Set("pid", o.Pid) // the pid is needed for Get and Put requests
+
+	return resourceOpenIdServerRead(ctx, d, m)
+}
+
+func resourceOpenIdServerRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	nodeId := d.Get("node_id").(string)
+	moduleId := d.Get("module_id").(string)
+	pid := d.Get("pid").(int)
+
+	server, err := c.GetOpenIdServer(nodeId, moduleId, pid)
+	if smilecdr.IsNotFound(err) || (err == nil && server.ArchivedAt != "") {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("pid", server.Pid)
+	d.Set("node_id", nodeId)
+	d.Set("module_id", moduleId)
+	d.Set("name", server.Name)
+	d.Set("issuer", server.Issuer)
+	d.Set("jwks_url", server.FedJwkSetUrl)
+	d.Set("validation_jwks", server.ValidationJwkText)
+	d.Set("federation_client_id", server.FedClientId)
+	// the client secret is write-only, Smile CDR masks it in responses
+	d.Set("authorization_url", server.FedAuthorizationUrl)
+	d.Set("token_url", server.FedTokenUrl)
+	d.Set("user_info_url", server.FedUserInfoUrl)
+	d.Set("request_scopes", strings.Fields(server.FedRequestScopes))
+	d.Set("token_mapping_script_hash", scriptHash(server.AuthScriptText))
+	if d.Get("token_mapping_script_file").(string) == "" {
+		d.Set("token_mapping_script", server.AuthScriptText)
+	}
+
+	return diags
+}
+
+func resourceOpenIdServerUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	server, mErr := resourceDataToOpenIdServer(d)
+	if mErr != nil {
+		return diag.FromErr(mErr)
+	}
+
+	_, err := c.PutOpenIdServer(*server)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceOpenIdServerRead(ctx, d, m)
+}
+
+func resourceOpenIdServerDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	err := c.DeleteOpenIdServer(d.Get("node_id").(string), d.Get("module_id").(string), d.Get("pid").(int))
+	if err != nil && !smilecdr.IsNotFound(err) {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
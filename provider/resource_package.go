@@ -0,0 +1,289 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/provider/util"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+// gzipMagic starts every .tgz package file.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+func resourcePackage() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourcePackageCreate,
+		ReadContext:   resourcePackageRead,
+		UpdateContext: resourcePackageUpdate,
+		DeleteContext: resourcePackageDelete,
+		CustomizeDiff: customdiff.All(
+			inheritProviderDefaults("node_id"),
+			resourcePackageCustomizeDiff,
+		),
+		Schema: map[string]*schema.Schema{
+			"node_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+			},
+			"module_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"version": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"package_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"package_url"},
+			},
+			"package_url": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"package_file"},
+				ValidateFunc:  validation.IsURLWithHTTPorHTTPS,
+			},
+			// the registry does not report how a package was installed, so the install
+			// settings are only written and changing them reinstalls the package
+			"install_mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      smilecdr.PackageInstallModeStoreAndInstall,
+				ValidateFunc: validation.StringInSlice([]string{smilecdr.PackageInstallModeStoreOnly, smilecdr.PackageInstallModeStoreAndInstall}, false),
+			},
+			"install_resource_types": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				ForceNew: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"fetch_dependencies": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  false,
+			},
+			"package_hash": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			// the files installed by the last install, as reported by its outcome
+			"installed_resources": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourcePackageImport,
+		},
+	}
+}
+
+// packageId builds the "node/module/name" resource ID, the version is not part of
+// it so that upgrades update the resource in place.
+func packageId(nodeId string, moduleId string, name string) string {
+	return nodeId + "/" + moduleId + "/" + name
+}
+
+func resourcePackageImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	// node/module/name@version
+	parts := strings.SplitN(d.Id(), "/", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("unexpected package ID %q, expected node/module/name@version", d.Id())
+	}
+	name, version, found := strings.Cut(parts[2], "@")
+	if !found {
+		return nil, fmt.Errorf("unexpected package ID %q, expected node/module/name@version", d.Id())
+	}
+	d.Set("node_id", parts[0])
+	d.Set("module_id", parts[1])
+	d.Set("name", name)
+	d.Set("version", version)
+	d.SetId(packageId(parts[0], parts[1], name))
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func readPackageFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("package_file: %s", err)
+	}
+	if !bytes.HasPrefix(content, gzipMagic) {
+		return nil, fmt.Errorf("package_file: %s is not a gzipped NPM package (.tgz)", path)
+	}
+	return content, nil
+}
+
+// resourcePackageCustomizeDiff plans a re-upload whenever the local package file
+// no longer matches the one that was installed.
+func resourcePackageCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("package_file") {
+		return d.SetNewComputed("package_hash")
+	}
+
+	hash := ""
+	if path := d.Get("package_file").(string); path != "" {
+		content, err := readPackageFile(path)
+		if err != nil {
+			return err
+		}
+		hash = util.HashContent(string(content))
+	}
+
+	if d.Get("package_hash").(string) != hash {
+		if err := d.SetNew("package_hash", hash); err != nil {
+			return err
+		}
+	}
+	if d.HasChanges("version", "package_hash", "install_mode", "install_resource_types") {
+		return d.SetNewComputed("installed_resources")
+	}
+	return nil
+}
+
+func resourceDataToPackageInstallationSpec(d *schema.ResourceData) (smilecdr.PackageInstallationSpec, error) {
+	spec := smilecdr.PackageInstallationSpec{
+		Name:                 d.Get("name").(string),
+		Version:              d.Get("version").(string),
+		PackageUrl:           d.Get("package_url").(string),
+		InstallMode:          d.Get("install_mode").(string),
+		InstallResourceTypes: stringsFromSet(d.Get("install_resource_types").(*schema.Set)),
+		FetchDependencies:    d.Get("fetch_dependencies").(bool),
+	}
+	if path := d.Get("package_file").(string); path != "" {
+		content, err := readPackageFile(path)
+		if err != nil {
+			return spec, err
+		}
+		spec.PackageContents = content
+	}
+	return spec, nil
+}
+
+func installPackage(d *schema.ResourceData, c *smilecdr.Client) error {
+	spec, err := resourceDataToPackageInstallationSpec(d)
+	if err != nil {
+		return err
+	}
+
+	outcome, err := c.InstallPackage(d.Get("node_id").(string), d.Get("module_id").(string), spec)
+	if err != nil {
+		return err
+	}
+
+	hash := ""
+	if spec.PackageContents != nil {
+		hash = util.HashContent(string(spec.PackageContents))
+	}
+	d.Set("package_hash", hash)
+	d.Set("installed_resources", outcome.FilesInstalled)
+
+	return nil
+}
+
+func resourcePackageCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	if err := installPackage(d, c); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(packageId(d.Get("node_id").(string), d.Get("module_id").(string), d.Get("name").(string)))
+
+	return resourcePackageRead(ctx, d, m)
+}
+
+func resourcePackageRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	npmPackage, err := c.GetPackage(d.Get("node_id").(string), d.Get("module_id").(string), d.Get("name").(string), d.Get("version").(string))
+	if smilecdr.IsNotFound(err) {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("name", npmPackage.Name)
+	d.Set("version", npmPackage.Version)
+
+	return diags
+}
+
+func resourcePackageUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	if d.HasChanges("version", "package_url", "package_hash") {
+		if err := installPackage(d, c); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	// an upgrade installs the new version first, so the package is never missing
+	if d.HasChange("version") {
+		oldVersion, _ := d.GetChange("version")
+		err := c.UninstallPackage(d.Get("node_id").(string), d.Get("module_id").(string), d.Get("name").(string), oldVersion.(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourcePackageRead(ctx, d, m)
+}
+
+func resourcePackageDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	err := c.UninstallPackage(d.Get("node_id").(string), d.Get("module_id").(string), d.Get("name").(string), d.Get("version").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
@@ -0,0 +1,183 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+var partitionNamePattern = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+
+func resourcePartition() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourcePartitionCreate,
+		ReadContext:   resourcePartitionRead,
+		UpdateContext: resourcePartitionUpdate,
+		DeleteContext: resourcePartitionDelete,
+		Schema: map[string]*schema.Schema{
+			"partition_id": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ValidateFunc: validation.All(
+					validation.StringMatch(partitionNamePattern, "may only contain letters, digits, _ and -"),
+					validation.StringNotInSlice([]string{smilecdr.DefaultPartitionName}, true),
+				),
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"force_destroy": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourcePartitionImport,
+		},
+	}
+}
+
+func resourcePartitionImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	id, err := strconv.Atoi(d.Id())
+	if err != nil {
+		return nil, fmt.Errorf("unexpected partition ID %q, expected a number", d.Id())
+	}
+	d.Set("partition_id", id)
+	d.Set("force_destroy", false)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceDataToPartition(d *schema.ResourceData) smilecdr.Partition {
+	return smilecdr.Partition{
+		Id:          d.Get("partition_id").(int),
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+	}
+}
+
+func resourcePartitionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	partition := resourceDataToPartition(d)
+
+	created, err := fhir.PostPartition(partition)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(strconv.Itoa(created.Id))
+
+	return resourcePartitionRead(ctx, d, m)
+}
+
+func resourcePartitionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id, err := strconv.Atoi(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	partition, err := fhir.GetPartition(id)
+	if smilecdr.IsNotFound(err) {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("partition_id", partition.Id)
+	d.Set("name", partition.Name)
+	d.Set("description", partition.Description)
+
+	return diags
+}
+
+func resourcePartitionUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if d.HasChanges("name", "description") {
+		_, err = fhir.PutPartition(resourceDataToPartition(d))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourcePartitionRead(ctx, d, m)
+}
+
+func resourcePartitionDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	partition := resourceDataToPartition(d)
+
+	if !d.Get("force_destroy").(bool) {
+		// fail closed, a partition whose contents cannot be counted is not deleted
+		count, err := fhir.ForPartition(partition.Name).CountResources()
+		if err != nil {
+			return diag.Errorf("unable to check partition %s for data before deleting it: %s", partition.Name, err)
+		}
+		if count > 0 {
+			return diag.Errorf("partition %s still holds %d resources, set force_destroy to delete it anyway", partition.Name, count)
+		}
+	}
+
+	if err := fhir.DeletePartition(partition.Id); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
@@ -0,0 +1,336 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/provider/util"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+// searchParameterIndexedAttributes change what is indexed, so changing them needs a reindex.
+var searchParameterIndexedAttributes = []string{"code", "base", "type", "expression", "component", "status"}
+
+func resourceSearchParameter() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSearchParameterCreate,
+		ReadContext:   resourceSearchParameterRead,
+		UpdateContext: resourceSearchParameterUpdate,
+		DeleteContext: resourceSearchParameterDelete,
+		CustomizeDiff: resourceSearchParameterCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"resource_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"url": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"code": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringMatch(searchParameterCodePattern, "must be a search parameter code, e.g. eye-colour"),
+			},
+			"base": {
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(smilecdr.SearchParameterTypes, false),
+			},
+			"expression": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: util.ValidateFhirPath,
+			},
+			"target": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"status": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "active",
+				ValidateFunc: validation.StringInSlice(smilecdr.PublicationStatuses, false),
+			},
+			"component": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"definition": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"expression": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: util.ValidateFhirPath,
+						},
+					},
+				},
+			},
+			"reindex": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"reindex_job_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"version_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+	}
+}
+
+var searchParameterCodePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_\-.:]*$`)
+
+func resourceSearchParameterCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("type") || !d.NewValueKnown("component") || !d.NewValueKnown("expression") {
+		return nil
+	}
+	searchType := d.Get("type").(string)
+	components := d.Get("component").([]interface{})
+	switch {
+	case searchType == "composite" && len(components) == 0:
+		return fmt.Errorf("a composite search parameter needs at least one component")
+	case searchType != "composite" && len(components) > 0:
+		return fmt.Errorf("only composite search parameters have components, this one is %s", searchType)
+	case searchType != "special" && d.Get("expression").(string) == "":
+		return fmt.Errorf("a %s search parameter needs an expression", searchType)
+	}
+	if d.Get("target").(*schema.Set).Len() > 0 && searchType != "reference" {
+		return fmt.Errorf("only reference search parameters have targets, this one is %s", searchType)
+	}
+	if d.HasChanges(searchParameterIndexedAttributes...) && d.Get("reindex").(bool) {
+		return d.SetNewComputed("reindex_job_id")
+	}
+	return nil
+}
+
+// stringsFromSet returns the members of a set of strings in sorted order.
+func stringsFromSet(set *schema.Set) []string {
+	values := make([]string, 0, set.Len())
+	for _, value := range set.List() {
+		values = append(values, value.(string))
+	}
+	sort.Strings(values)
+	return values
+}
+
+func resourceDataToSearchParameter(d *schema.ResourceData) smilecdr.SearchParameter {
+	name := d.Get("name").(string)
+	if name == "" {
+		name = d.Get("code").(string)
+	}
+
+	var components []smilecdr.SearchParameterComponent
+	for _, item := range d.Get("component").([]interface{}) {
+		component := item.(map[string]interface{})
+		components = append(components, smilecdr.SearchParameterComponent{
+			Definition: component["definition"].(string),
+			Expression: component["expression"].(string),
+		})
+	}
+
+	return smilecdr.SearchParameter{
+		Id:          d.Get("resource_id").(string),
+		Url:         d.Get("url").(string),
+		Name:        name,
+		Status:      d.Get("status").(string),
+		Description: d.Get("description").(string),
+		Code:        d.Get("code").(string),
+		Base:        stringsFromSet(d.Get("base").(*schema.Set)),
+		Type:        d.Get("type").(string),
+		Expression:  d.Get("expression").(string),
+		Target:      stringsFromSet(d.Get("target").(*schema.Set)),
+		Component:   components,
+	}
+}
+
+// reindexSearchParameter reindexes the resource types the search parameter applies
+// to and waits for the job to finish.
+func reindexSearchParameter(ctx context.Context, d *schema.ResourceData, c *smilecdr.Client, fhir *smilecdr.FhirClient, timeout time.Duration) error {
+	var resourceTypes []string
+	for _, base := range stringsFromSet(d.Get("base").(*schema.Set)) {
+		if base == "Resource" || base == "DomainResource" {
+			// applies to every resource type
+			resourceTypes = nil
+			break
+		}
+		resourceTypes = append(resourceTypes, base)
+	}
+
+	jobId, err := fhir.Reindex(resourceTypes)
+	if err != nil {
+		return err
+	}
+	d.Set("reindex_job_id", jobId)
+
+	return waitForBatchJob(ctx, c, jobId, timeout)
+}
+
+func resourceSearchParameterCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	searchParameter := resourceDataToSearchParameter(d)
+
+	created, err := fhir.PostSearchParameter(searchParameter)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(created.Id)
+	d.Set("resource_id", created.Id)
+
+	if d.Get("reindex").(bool) {
+		if err := reindexSearchParameter(ctx, d, c, fhir, d.Timeout(schema.TimeoutCreate)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceSearchParameterRead(ctx, d, m)
+}
+
+func resourceSearchParameterRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	searchParameter, err := fhir.GetSearchParameter(d.Id())
+	if smilecdr.IsNotFound(err) || smilecdr.IsGone(err) {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	components := make([]interface{}, 0, len(searchParameter.Component))
+	for _, component := range searchParameter.Component {
+		components = append(components, map[string]interface{}{
+			"definition": component.Definition,
+			"expression": component.Expression,
+		})
+	}
+
+	d.Set("resource_id", searchParameter.Id)
+	d.Set("url", searchParameter.Url)
+	d.Set("name", searchParameter.Name)
+	d.Set("description", searchParameter.Description)
+	d.Set("code", searchParameter.Code)
+	d.Set("base", searchParameter.Base)
+	d.Set("type", searchParameter.Type)
+	d.Set("expression", searchParameter.Expression)
+	d.Set("target", searchParameter.Target)
+	d.Set("status", searchParameter.Status)
+	d.Set("component", components)
+	if searchParameter.Meta != nil {
+		d.Set("version_id", searchParameter.Meta.VersionId)
+	}
+
+	return diags
+}
+
+func resourceSearchParameterUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	searchParameter := resourceDataToSearchParameter(d)
+
+	_, err = fhir.PutSearchParameter(searchParameter)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if d.Get("reindex").(bool) && d.HasChanges(searchParameterIndexedAttributes...) {
+		if err := reindexSearchParameter(ctx, d, c, fhir, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceSearchParameterRead(ctx, d, m)
+}
+
+func resourceSearchParameterDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := fhir.DeleteSearchParameter(d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
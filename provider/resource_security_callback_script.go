@@ -0,0 +1,205 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zed-werks/terraform-smilecdr/provider/util"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceSecurityCallbackScript() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSecurityCallbackScriptCreate,
+		ReadContext:   resourceSecurityCallbackScriptRead,
+		UpdateContext: resourceSecurityCallbackScriptUpdate,
+		DeleteContext: resourceSecurityCallbackScriptDelete,
+		CustomizeDiff: customdiff.All(
+			inheritProviderDefaults("node_id"),
+			func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+				return customizeScriptDiff(d, "script", "script_file", "script_hash")
+			},
+		),
+		Schema: map[string]*schema.Schema{
+			"node_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+			},
+			"module_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"script": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: util.ValidateJavaScript,
+				ExactlyOneOf: []string{"script", "script_file"},
+			},
+			"script_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"script", "script_file"},
+			},
+			"script_hash": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceModuleConfigImport,
+		},
+	}
+}
+
+// scriptFromResourceData returns the script set inline under scriptKey, or the
+// contents of the file named by fileKey.
+func scriptFromResourceData(d *schema.ResourceData, scriptKey string, fileKey string) (string, error) {
+	path := d.Get(fileKey).(string)
+	if path == "" {
+		return d.Get(scriptKey).(string), nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", fileKey, err)
+	}
+	return string(content), nil
+}
+
+// customizeScriptDiff syntax checks a script kept in a file and plans a new
+// content hash whenever the local script no longer matches the one on the server.
+func customizeScriptDiff(d *schema.ResourceDiff, scriptKey string, fileKey string, hashKey string) error {
+	if !d.NewValueKnown(fileKey) || !d.NewValueKnown(scriptKey) {
+		return d.SetNewComputed(hashKey)
+	}
+
+	script := d.Get(scriptKey).(string)
+	if path := d.Get(fileKey).(string); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %s", fileKey, err)
+		}
+		if err := util.CheckJavaScript(path, string(content)); err != nil {
+			return err
+		}
+		script = string(content)
+	}
+
+	if hash := scriptHash(script); d.Get(hashKey).(string) != hash {
+		return d.SetNew(hashKey, hash)
+	}
+	return nil
+}
+
+func scriptHash(script string) string {
+	if script == "" {
+		return ""
+	}
+	return util.HashContent(script)
+}
+
+func setSecurityCallbackScript(c *smilecdr.Client, d *schema.ResourceData, script string) error {
+	config := smilecdr.ModuleConfig{
+		NodeId:   d.Get("node_id").(string),
+		ModuleId: d.Get("module_id").(string),
+		Options:  []smilecdr.ModuleOption{{Key: smilecdr.ModuleCallbackScriptOption, Value: script}},
+	}
+	return mergeModuleConfig(c, config)
+}
+
+func resourceSecurityCallbackScriptCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	script, err := scriptFromResourceData(d, "script", "script_file")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := setSecurityCallbackScript(c, d, script); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(moduleConfigId(d.Get("node_id").(string), d.Get("module_id").(string)))
+
+	return resourceSecurityCallbackScriptRead(ctx, d, m)
+}
+
+func resourceSecurityCallbackScriptRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	nodeId := d.Get("node_id").(string)
+	moduleId := d.Get("module_id").(string)
+
+	config, err := c.GetModuleConfig(nodeId, moduleId)
+	if smilecdr.IsNotFound(err) || (err == nil && config.ArchivedAt != "") {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	script := config.Option(smilecdr.ModuleCallbackScriptOption)
+
+	d.Set("node_id", nodeId)
+	d.Set("module_id", moduleId)
+	d.Set("script_hash", scriptHash(script))
+	if d.Get("script_file").(string) == "" {
+		d.Set("script", script)
+	}
+
+	return diags
+}
+
+func resourceSecurityCallbackScriptUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	script, err := scriptFromResourceData(d, "script", "script_file")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := setSecurityCallbackScript(c, d, script); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceSecurityCallbackScriptRead(ctx, d, m)
+}
+
+func resourceSecurityCallbackScriptDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	err := setSecurityCallbackScript(c, d, "")
+	if err != nil && !smilecdr.IsNotFound(err) {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
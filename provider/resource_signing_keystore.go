@@ -0,0 +1,450 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/provider/util"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+const (
+	signingKeyActive   = "ACTIVE"
+	signingKeyRetiring = "RETIRING"
+)
+
+func resourceSigningKeystore() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSigningKeystoreCreate,
+		ReadContext:   resourceSigningKeystoreRead,
+		UpdateContext: resourceSigningKeystoreUpdate,
+		DeleteContext: resourceSigningKeystoreDelete,
+		CustomizeDiff: customdiff.All(
+			inheritProviderDefaults("node_id", "module_id"),
+			resourceSigningKeystoreCustomizeDiff,
+		),
+		Schema: map[string]*schema.Schema{
+			"node_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+			},
+			"module_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+			},
+			"token_lifetime_seconds": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3600,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"key": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"kid": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringIsNotWhiteSpace,
+						},
+						"algorithm": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(util.SigningAlgorithms, false),
+						},
+						"status": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      signingKeyActive,
+							ValidateFunc: validation.StringInSlice([]string{signingKeyActive, signingKeyRetiring}, false),
+						},
+						"private_key_pem": {
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+						"rsa_bits": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      2048,
+							ValidateFunc: validation.IntInSlice([]int{2048, 3072, 4096}),
+						},
+					},
+				},
+			},
+			"private_keys": {
+				Type:      schema.TypeMap,
+				Computed:  true,
+				Sensitive: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"retired_at": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"active_kid": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"public_jwks": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceSigningKeystoreImport,
+		},
+	}
+}
+
+type signingKey struct {
+	kid           string
+	algorithm     string
+	status        string
+	privateKeyPem string
+	rsaBits       int
+}
+
+func signingKeysFromList(list []interface{}) []signingKey {
+	keys := make([]signingKey, 0, len(list))
+	for _, item := range list {
+		key := item.(map[string]interface{})
+		keys = append(keys, signingKey{
+			kid:           key["kid"].(string),
+			algorithm:     key["algorithm"].(string),
+			status:        key["status"].(string),
+			privateKeyPem: key["private_key_pem"].(string),
+			rsaBits:       key["rsa_bits"].(int),
+		})
+	}
+	return keys
+}
+
+// publicJwks renders the public half of a key set in a stable form, so the
+// published keys on the server can be compared with the configured ones.
+func publicJwks(keys []util.JSONWebKey) string {
+	set := util.JSONWebKeySet{Keys: make([]util.JSONWebKey, 0, len(keys))}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, key.Public())
+	}
+	out, _ := json.Marshal(set)
+	return string(out)
+}
+
+// orderedJwks puts the active key first, Smile CDR signs new tokens with the first key.
+func orderedJwks(keys []signingKey, jwks map[string]util.JSONWebKey) []util.JSONWebKey {
+	ordered := make([]util.JSONWebKey, 0, len(keys))
+	for _, status := range []string{signingKeyActive, signingKeyRetiring} {
+		for _, key := range keys {
+			if key.status == status {
+				ordered = append(ordered, jwks[key.kid])
+			}
+		}
+	}
+	return ordered
+}
+
+// resourceSigningKeystoreCustomizeDiff enforces one active key and a safe rollover:
+// a key may only be dropped once it has been retiring for longer than the token
+// lifetime, so every token it signed has expired.
+func resourceSigningKeystoreCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("key") {
+		return nil
+	}
+
+	oldList, newList := d.GetChange("key")
+	keys := signingKeysFromList(newList.([]interface{}))
+
+	active := 0
+	kids := make(map[string]bool)
+	for _, key := range keys {
+		if kids[key.kid] {
+			return fmt.Errorf("key %s is listed more than once", key.kid)
+		}
+		kids[key.kid] = true
+		if key.status == signingKeyActive {
+			active++
+		}
+	}
+	if active != 1 {
+		return fmt.Errorf("exactly one key must be %s, found %d", signingKeyActive, active)
+	}
+
+	retiredAt := d.Get("retired_at").(map[string]interface{})
+	lifetime := time.Duration(d.Get("token_lifetime_seconds").(int)) * time.Second
+	for _, old := range signingKeysFromList(oldList.([]interface{})) {
+		if kids[old.kid] {
+			continue
+		}
+		if old.status != signingKeyRetiring {
+			return fmt.Errorf("key %s is %s, set its status to %s before removing it", old.kid, old.status, signingKeyRetiring)
+		}
+		// without a valid retirement time the key cannot be shown to be unused
+		recorded, _ := retiredAt[old.kid].(string)
+		since, err := time.Parse(time.RFC3339, recorded)
+		if err != nil {
+			return fmt.Errorf("key %s has no valid retirement time (%q), keep it listed as %s and apply to start its retirement", old.kid, recorded, signingKeyRetiring)
+		}
+		if until := since.Add(lifetime); time.Now().Before(until) {
+			return fmt.Errorf("key %s may still have signed unexpired tokens, keep it until %s", old.kid, until.Format(time.RFC3339))
+		}
+	}
+
+	if d.HasChange("key") {
+		for _, attr := range []string{"private_keys", "retired_at", "active_kid", "public_jwks"} {
+			if err := d.SetNewComputed(attr); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// keys unchanged, compare what is published on the server with what should be
+	privateKeys := d.Get("private_keys").(map[string]interface{})
+	jwks := make(map[string]util.JSONWebKey)
+	for _, key := range keys {
+		pem := key.privateKeyPem
+		if pem == "" {
+			pem, _ = privateKeys[key.kid].(string)
+		}
+		signer, err := util.ParsePrivateKeyPEM(pem, key.algorithm)
+		if err != nil {
+			return d.SetNewComputed("public_jwks")
+		}
+		jwk, err := util.PublicJWK(signer.Public(), key.kid, key.algorithm)
+		if err != nil {
+			return err
+		}
+		jwks[key.kid] = jwk
+	}
+	if publicJwks(orderedJwks(keys, jwks)) != d.Get("public_jwks").(string) {
+		return d.SetNewComputed("public_jwks")
+	}
+
+	return nil
+}
+
+func applySigningKeystore(d *schema.ResourceData, c *smilecdr.Client) error {
+
+	keys := signingKeysFromList(d.Get("key").([]interface{}))
+	oldPrivateKeys, _ := d.GetChange("private_keys")
+	oldRetiredAt, _ := d.GetChange("retired_at")
+
+	privateKeys := make(map[string]interface{})
+	retiredAt := make(map[string]interface{})
+	jwks := make(map[string]util.JSONWebKey)
+	activeKid := ""
+
+	for _, key := range keys {
+		pem := key.privateKeyPem
+		if pem == "" {
+			pem, _ = oldPrivateKeys.(map[string]interface{})[key.kid].(string)
+		}
+		if pem == "" {
+			signer, err := util.GenerateSigningKey(key.algorithm, key.rsaBits)
+			if err != nil {
+				return err
+			}
+			if pem, err = util.EncodePrivateKeyPEM(signer); err != nil {
+				return err
+			}
+		}
+		if key.privateKeyPem == "" {
+			privateKeys[key.kid] = pem
+		}
+
+		signer, err := util.ParsePrivateKeyPEM(pem, key.algorithm)
+		if err != nil {
+			return fmt.Errorf("key %s: %s", key.kid, err)
+		}
+		if jwks[key.kid], err = util.PrivateJWK(signer, key.kid, key.algorithm); err != nil {
+			return fmt.Errorf("key %s: %s", key.kid, err)
+		}
+
+		if key.status == signingKeyActive {
+			activeKid = key.kid
+		} else if since, ok := oldRetiredAt.(map[string]interface{})[key.kid]; ok {
+			retiredAt[key.kid] = since
+		} else {
+			retiredAt[key.kid] = time.Now().UTC().Format(time.RFC3339)
+		}
+	}
+
+	jwksText, err := json.Marshal(util.JSONWebKeySet{Keys: orderedJwks(keys, jwks)})
+	if err != nil {
+		return err
+	}
+
+	config := smilecdr.ModuleConfig{
+		NodeId:   d.Get("node_id").(string),
+		ModuleId: d.Get("module_id").(string),
+		Options:  []smilecdr.ModuleOption{{Key: smilecdr.SmartAuthSigningJwksOption, Value: string(jwksText)}},
+	}
+	if err := mergeModuleConfig(c, config); err != nil {
+		return err
+	}
+
+	d.Set("private_keys", privateKeys)
+	d.Set("retired_at", retiredAt)
+	d.Set("active_kid", activeKid)
+
+	return nil
+}
+
+// resourceSigningKeystoreImport recovers the keys from the key set published on the
+// module. The first key signs new tokens and becomes the active key, the others are
+// treated as retiring from the time of the import.
+func resourceSigningKeystoreImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	c, err := providerClient(m)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeId, moduleId, err := parseModuleConfigId(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := c.GetModuleConfig(nodeId, moduleId)
+	if err != nil {
+		return nil, err
+	}
+	var published util.JSONWebKeySet
+	if err := json.Unmarshal([]byte(config.Option(smilecdr.SmartAuthSigningJwksOption)), &published); err != nil {
+		return nil, fmt.Errorf("the signing key set published on module %s is not valid JSON: %s", moduleId, err)
+	}
+	if len(published.Keys) == 0 {
+		return nil, fmt.Errorf("module %s publishes no signing keys", moduleId)
+	}
+
+	keys := make([]interface{}, 0, len(published.Keys))
+	privateKeys := make(map[string]interface{})
+	retiredAt := make(map[string]interface{})
+	now := time.Now().UTC().Format(time.RFC3339)
+	for i, jwk := range published.Keys {
+		signer, err := jwk.PrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		pem, err := util.EncodePrivateKeyPEM(signer)
+		if err != nil {
+			return nil, err
+		}
+		privateKeys[jwk.Kid] = pem
+
+		rsaBits := 2048
+		if key, ok := signer.(*rsa.PrivateKey); ok {
+			rsaBits = key.N.BitLen()
+		}
+		status := signingKeyActive
+		if i > 0 {
+			status = signingKeyRetiring
+			retiredAt[jwk.Kid] = now
+		}
+		keys = append(keys, map[string]interface{}{
+			"kid":             jwk.Kid,
+			"algorithm":       jwk.Alg,
+			"status":          status,
+			"private_key_pem": "",
+			"rsa_bits":        rsaBits,
+		})
+	}
+
+	d.Set("node_id", nodeId)
+	d.Set("module_id", moduleId)
+	d.Set("key", keys)
+	d.Set("private_keys", privateKeys)
+	d.Set("retired_at", retiredAt)
+	d.Set("active_kid", published.Keys[0].Kid)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceSigningKeystoreCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	if err := applySigningKeystore(d, c); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(moduleConfigId(d.Get("node_id").(string), d.Get("module_id").(string)))
+
+	return resourceSigningKeystoreRead(ctx, d, m)
+}
+
+func resourceSigningKeystoreRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	config, err := c.GetModuleConfig(d.Get("node_id").(string), d.Get("module_id").(string))
+	if smilecdr.IsNotFound(err) || (err == nil && config.ArchivedAt != "") {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var published util.JSONWebKeySet
+	if text := config.Option(smilecdr.SmartAuthSigningJwksOption); text != "" {
+		if err := json.Unmarshal([]byte(text), &published); err != nil {
+			return diag.Errorf("the signing key set published on module %s is not valid JSON: %s", config.ModuleId, err)
+		}
+	}
+
+	d.Set("public_jwks", publicJwks(published.Keys))
+
+	return diags
+}
+
+func resourceSigningKeystoreUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	if err := applySigningKeystore(d, c); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceSigningKeystoreRead(ctx, d, m)
+}
+
+func resourceSigningKeystoreDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	// the published keys stay on the module, removing them would stop it signing tokens
+	d.SetId("")
+
+	return diags
+}
@@ -0,0 +1,256 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceSmartAuthModule() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSmartAuthModuleCreate,
+		ReadContext:   resourceSmartAuthModuleRead,
+		UpdateContext: resourceSmartAuthModuleUpdate,
+		DeleteContext: resourceModuleConfigDelete,
+		CustomizeDiff: customdiff.All(
+			inheritProviderDefaults("node_id"),
+			resourceSmartAuthModuleCustomizeDiff,
+		),
+		Schema: map[string]*schema.Schema{
+			"node_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+			},
+			"module_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"issuer_url": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"port": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validation.IsPortNumber,
+			},
+			"access_token_validity_seconds": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      300,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"refresh_token_validity_seconds": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      86400,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"id_token_validity_seconds": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      300,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"signing_keystore_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"approval_page_enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"approval_page_template": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"remember_approved_scopes": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"consent_page_enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"consent_page_template": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"federated_login_enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"federated_login_skip_local": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"security_module_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceModuleConfigImport,
+		},
+	}
+}
+
+// resourceSmartAuthModuleCustomizeDiff checks the inbound security dependency and
+// the listener port against the modules already on the node.
+func resourceSmartAuthModuleCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	c, ok := m.(*smilecdr.Client)
+	if !ok || !d.NewValueKnown("node_id") {
+		return nil
+	}
+
+	nodeId := d.Get("node_id").(string)
+
+	node, err := c.GetModuleConfigs(nodeId)
+	if err != nil {
+		return fmt.Errorf("unable to list the modules on node %s: %s", nodeId, err)
+	}
+
+	if d.NewValueKnown("security_module_id") {
+		ref := d.Get("security_module_id").(string)
+		if err := checkModuleDependency(node, ref, smilecdr.SecurityInModuleTypePrefix); err != nil {
+			return fmt.Errorf("security_module_id: %s", err)
+		}
+	}
+
+	if d.NewValueKnown("port") {
+		if err := checkListenerPort(node, d.Get("module_id").(string), d.Get("port").(int)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resourceDataToSmartAuthModule(d *schema.ResourceData) smilecdr.SmartAuthModule {
+	return smilecdr.SmartAuthModule{
+		NodeId:                      d.Get("node_id").(string),
+		ModuleId:                    d.Get("module_id").(string),
+		IssuerUrl:                   d.Get("issuer_url").(string),
+		Port:                        d.Get("port").(int),
+		AccessTokenValiditySeconds:  d.Get("access_token_validity_seconds").(int),
+		RefreshTokenValiditySeconds: d.Get("refresh_token_validity_seconds").(int),
+		IdTokenValiditySeconds:      d.Get("id_token_validity_seconds").(int),
+		SigningKeystoreId:           d.Get("signing_keystore_id").(string),
+		ApprovalPageEnabled:         d.Get("approval_page_enabled").(bool),
+		ApprovalPageTemplate:        d.Get("approval_page_template").(string),
+		RememberApprovedScopes:      d.Get("remember_approved_scopes").(bool),
+		ConsentPageEnabled:          d.Get("consent_page_enabled").(bool),
+		ConsentPageTemplate:         d.Get("consent_page_template").(string),
+		FederatedLoginEnabled:       d.Get("federated_login_enabled").(bool),
+		FederatedLoginSkipLocal:     d.Get("federated_login_skip_local").(bool),
+		SecurityModuleId:            moduleIdFromRef(d.Get("security_module_id").(string)),
+	}
+}
+
+func resourceSmartAuthModuleCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	module := resourceDataToSmartAuthModule(d)
+
+	if err := upsertModuleConfig(c, module.ModuleConfig()); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(moduleConfigId(module.NodeId, module.ModuleId))
+
+	return resourceSmartAuthModuleRead(ctx, d, m)
+}
+
+func resourceSmartAuthModuleRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	nodeId := d.Get("node_id").(string)
+	moduleId := d.Get("module_id").(string)
+
+	config, err := c.GetModuleConfig(nodeId, moduleId)
+	if smilecdr.IsNotFound(err) || (err == nil && config.ArchivedAt != "") {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	module, err := smilecdr.SmartAuthModuleFromConfig(config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("node_id", nodeId)
+	d.Set("module_id", module.ModuleId)
+	d.Set("issuer_url", module.IssuerUrl)
+	d.Set("port", module.Port)
+	d.Set("access_token_validity_seconds", module.AccessTokenValiditySeconds)
+	d.Set("refresh_token_validity_seconds", module.RefreshTokenValiditySeconds)
+	d.Set("id_token_validity_seconds", module.IdTokenValiditySeconds)
+	d.Set("signing_keystore_id", module.SigningKeystoreId)
+	d.Set("approval_page_enabled", module.ApprovalPageEnabled)
+	d.Set("approval_page_template", module.ApprovalPageTemplate)
+	d.Set("remember_approved_scopes", module.RememberApprovedScopes)
+	d.Set("consent_page_enabled", module.ConsentPageEnabled)
+	d.Set("consent_page_template", module.ConsentPageTemplate)
+	d.Set("federated_login_enabled", module.FederatedLoginEnabled)
+	d.Set("federated_login_skip_local", module.FederatedLoginSkipLocal)
+
+	// keep a "node/module" reference as written while it still points at the same module
+	if moduleIdFromRef(d.Get("security_module_id").(string)) != module.SecurityModuleId {
+		d.Set("security_module_id", module.SecurityModuleId)
+	}
+
+	return diags
+}
+
+func resourceSmartAuthModuleUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	module := resourceDataToSmartAuthModule(d)
+
+	// cleared templates and keystore are removed from the module
+	previous := module
+	oldKeystoreId, _ := d.GetChange("signing_keystore_id")
+	oldApprovalTemplate, _ := d.GetChange("approval_page_template")
+	oldConsentTemplate, _ := d.GetChange("consent_page_template")
+	previous.SigningKeystoreId = oldKeystoreId.(string)
+	previous.ApprovalPageTemplate = oldApprovalTemplate.(string)
+	previous.ConsentPageTemplate = oldConsentTemplate.(string)
+
+	if err := mergeModuleConfig(c, module.ModuleConfig(), module.ClearedOptions(previous)...); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceSmartAuthModuleRead(ctx, d, m)
+}
@@ -0,0 +1,477 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/provider/util"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceSubscription() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSubscriptionCreate,
+		ReadContext:   resourceSubscriptionRead,
+		UpdateContext: resourceSubscriptionUpdate,
+		DeleteContext: resourceSubscriptionDelete,
+		CustomizeDiff: customdiff.All(
+			resourceSubscriptionCustomizeDiff,
+			checkVersionConstraints(subscriptionVersionConstraints),
+		),
+		Schema: map[string]*schema.Schema{
+			"resource_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"fhir_version": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "R4",
+				ValidateFunc: validation.StringInSlice([]string{"R4", "R5"}, false),
+			},
+			"fhir_base_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"criteria": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"criteria", "topic"},
+				ValidateFunc: util.ValidateSearchCriteria,
+			},
+			"topic": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"criteria", "topic"},
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"filter_criteria": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: util.ValidateSearchCriteria,
+				},
+			},
+			"reason": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"channel_type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(smilecdr.SubscriptionChannelTypes, false),
+			},
+			"endpoint": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"headers": {
+				Type:      schema.TypeMap,
+				Optional:  true,
+				Sensitive: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"payload": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"content": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(smilecdr.SubscriptionContents, false),
+			},
+			"enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"version_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+	}
+}
+
+// subscriptionVersionConstraints covers topic-based subscriptions, which arrived
+// with FHIR R5 support.
+var subscriptionVersionConstraints = map[string]versionConstraint{
+	"topic":           {MinVersion: "2023.05"},
+	"filter_criteria": {MinVersion: "2023.05"},
+}
+
+func resourceSubscriptionCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if d.Get("fhir_version").(string) == "R5" && d.Get("criteria").(string) != "" {
+		return fmt.Errorf("R5 subscriptions are topic-based, set topic instead of criteria")
+	}
+	if d.Get("fhir_version").(string) == "R5" && d.NewValueKnown("fhir_base_url") && d.Get("fhir_base_url").(string) == "" {
+		return fmt.Errorf("R5 subscriptions need fhir_base_url set to an R5 FHIR endpoint, the provider's fhir_base_url serves R4")
+	}
+
+	// a topic set from another resource is unknown until apply
+	if d.NewValueKnown("topic") && d.Get("topic").(string) == "" {
+		if len(d.Get("filter_criteria").([]interface{})) > 0 {
+			return fmt.Errorf("filter_criteria only applies to topic-based subscriptions")
+		}
+		if d.Get("content").(string) != "" {
+			return fmt.Errorf("content only applies to topic-based subscriptions")
+		}
+	}
+
+	if d.NewValueKnown("channel_type") && d.NewValueKnown("endpoint") {
+		channelType := d.Get("channel_type").(string)
+		endpoint := d.Get("endpoint").(string)
+		if channelType != "websocket" && endpoint == "" {
+			return fmt.Errorf("a %s subscription needs an endpoint", channelType)
+		}
+	}
+
+	if d.HasChange("enabled") {
+		return d.SetNewComputed("status")
+	}
+	return nil
+}
+
+// subscriptionHeaders renders the headers map as "Name: value" strings in a stable order.
+func subscriptionHeaders(headers map[string]interface{}) []string {
+	var lines []string
+	for name, value := range headers {
+		lines = append(lines, fmt.Sprintf("%s: %s", name, value))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func flattenSubscriptionHeaders(lines []string) map[string]interface{} {
+	headers := make(map[string]interface{})
+	for _, line := range lines {
+		name, value, _ := strings.Cut(line, ":")
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return headers
+}
+
+// filterByFromCriteria turns "Encounter?patient=Patient/1&status=finished" into R5
+// filterBy entries, keeping the parameter order so the criteria read back unchanged.
+func filterByFromCriteria(criteria string) []smilecdr.SubscriptionFilterBy {
+	var filters []smilecdr.SubscriptionFilterBy
+	resourceType, query, _ := strings.Cut(criteria, "?")
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		parameter, modifier, _ := strings.Cut(name, ":")
+		filters = append(filters, smilecdr.SubscriptionFilterBy{
+			ResourceType:    resourceType,
+			FilterParameter: parameter,
+			Modifier:        modifier,
+			Value:           value,
+		})
+	}
+	return filters
+}
+
+func criteriaFromFilterBy(filters []smilecdr.SubscriptionFilterBy) []string {
+	var criteria []string
+	current := ""
+	for i, filter := range filters {
+		name := filter.FilterParameter
+		if filter.Modifier != "" {
+			name += ":" + filter.Modifier
+		}
+		if i == 0 || filter.ResourceType != filters[i-1].ResourceType {
+			if current != "" {
+				criteria = append(criteria, current)
+			}
+			current = filter.ResourceType + "?" + name + "=" + filter.Value
+		} else {
+			current += "&" + name + "=" + filter.Value
+		}
+	}
+	if current != "" {
+		criteria = append(criteria, current)
+	}
+	return criteria
+}
+
+func resourceDataToSubscription(d *schema.ResourceData) smilecdr.Subscription {
+	status := smilecdr.SubscriptionStatusRequested
+	if !d.Get("enabled").(bool) {
+		status = smilecdr.SubscriptionStatusOff
+	}
+
+	var filterCriteria []string
+	for _, criteria := range d.Get("filter_criteria").([]interface{}) {
+		filterCriteria = append(filterCriteria, criteria.(string))
+	}
+
+	subscription := smilecdr.Subscription{
+		Id:     d.Get("resource_id").(string),
+		Status: status,
+		Reason: d.Get("reason").(string),
+	}
+	channelType := d.Get("channel_type").(string)
+	endpoint := d.Get("endpoint").(string)
+	headers := subscriptionHeaders(d.Get("headers").(map[string]interface{}))
+	payload := d.Get("payload").(string)
+	topic := d.Get("topic").(string)
+	content := d.Get("content").(string)
+
+	if d.Get("fhir_version").(string) == "R5" {
+		subscription.Topic = topic
+		for _, criteria := range filterCriteria {
+			subscription.FilterBy = append(subscription.FilterBy, filterByFromCriteria(criteria)...)
+		}
+		subscription.ChannelType = &smilecdr.Coding{System: smilecdr.SubscriptionChannelTypeSystem, Code: channelType}
+		subscription.Endpoint = endpoint
+		subscription.Header = headers
+		subscription.ContentType = payload
+		subscription.Content = content
+		return subscription
+	}
+
+	subscription.Channel = &smilecdr.SubscriptionChannel{
+		Type:     channelType,
+		Endpoint: endpoint,
+		Payload:  payload,
+		Header:   headers,
+	}
+	if topic == "" {
+		subscription.Criteria = d.Get("criteria").(string)
+		return subscription
+	}
+
+	// R4 topic-based subscriptions follow the subscriptions backport IG
+	subscription.Meta = &smilecdr.Meta{Profile: []string{smilecdr.BackportSubscriptionProfile}}
+	subscription.Criteria = topic
+	if len(filterCriteria) > 0 {
+		subscription.CriteriaElement = &smilecdr.Element{}
+		for _, criteria := range filterCriteria {
+			subscription.CriteriaElement.Extension = append(subscription.CriteriaElement.Extension,
+				smilecdr.Extension{Url: smilecdr.BackportFilterCriteriaExtension, ValueString: criteria})
+		}
+	}
+	if content != "" {
+		subscription.Channel.PayloadElement = &smilecdr.Element{
+			Extension: []smilecdr.Extension{{Url: smilecdr.BackportPayloadContentExtension, ValueCode: content}},
+		}
+	}
+	return subscription
+}
+
+func isBackportSubscription(subscription smilecdr.Subscription) bool {
+	return subscription.Meta != nil && util.Contains(subscription.Meta.Profile, smilecdr.BackportSubscriptionProfile)
+}
+
+// waitForSubscriptionActive polls until Smile CDR has activated the subscription.
+func waitForSubscriptionActive(ctx context.Context, fhir *smilecdr.FhirClient, id string, timeout time.Duration) error {
+	stateConf := &retry.StateChangeConf{
+		Pending: []string{smilecdr.SubscriptionStatusRequested},
+		Target:  []string{smilecdr.SubscriptionStatusActive},
+		Refresh: func() (interface{}, string, error) {
+			subscription, err := fhir.GetSubscription(id)
+			if err != nil {
+				return nil, "", err
+			}
+			if subscription.Status == smilecdr.SubscriptionStatusError {
+				return subscription, subscription.Status, fmt.Errorf("subscription %s failed to activate: %s", id, subscription.Error)
+			}
+			return subscription, subscription.Status, nil
+		},
+		Timeout:    timeout,
+		Delay:      1 * time.Second,
+		MinTimeout: 2 * time.Second,
+	}
+
+	_, err := stateConf.WaitForStateContext(ctx)
+	return err
+}
+
+// subscriptionFhirClient returns the FHIR client for the resource's fhir_base_url,
+// or the provider's FHIR endpoint when it has none.
+func subscriptionFhirClient(d *schema.ResourceData, m interface{}) (*smilecdr.FhirClient, error) {
+	c, err := providerClient(m)
+	if err != nil {
+		return nil, err
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return nil, err
+	}
+	return fhir.WithBaseUrl(d.Get("fhir_base_url").(string)), nil
+}
+
+func resourceSubscriptionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	fhir, err := subscriptionFhirClient(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	subscription := resourceDataToSubscription(d)
+
+	created, err := fhir.PostSubscription(subscription)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(created.Id)
+	d.Set("resource_id", created.Id)
+
+	if d.Get("enabled").(bool) {
+		if err := waitForSubscriptionActive(ctx, fhir, created.Id, d.Timeout(schema.TimeoutCreate)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceSubscriptionRead(ctx, d, m)
+}
+
+func resourceSubscriptionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	fhir, err := subscriptionFhirClient(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	subscription, err := fhir.GetSubscription(d.Id())
+	if smilecdr.IsNotFound(err) || smilecdr.IsGone(err) {
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("resource_id", subscription.Id)
+	d.Set("reason", subscription.Reason)
+	d.Set("status", subscription.Status)
+	if subscription.Meta != nil {
+		d.Set("version_id", subscription.Meta.VersionId)
+	}
+
+	// a subscription in error no longer delivers, report it as disabled so the next
+	// apply requests it again
+	d.Set("enabled", subscription.Status == smilecdr.SubscriptionStatusRequested || subscription.Status == smilecdr.SubscriptionStatusActive)
+
+	if subscription.ChannelType != nil {
+		d.Set("fhir_version", "R5")
+		d.Set("topic", subscription.Topic)
+		d.Set("filter_criteria", criteriaFromFilterBy(subscription.FilterBy))
+		d.Set("channel_type", subscription.ChannelType.Code)
+		d.Set("endpoint", subscription.Endpoint)
+		d.Set("headers", flattenSubscriptionHeaders(subscription.Header))
+		d.Set("payload", subscription.ContentType)
+		d.Set("content", subscription.Content)
+		return diags
+	}
+
+	d.Set("fhir_version", "R4")
+	if subscription.Channel != nil {
+		d.Set("channel_type", subscription.Channel.Type)
+		d.Set("endpoint", subscription.Channel.Endpoint)
+		d.Set("headers", flattenSubscriptionHeaders(subscription.Channel.Header))
+		d.Set("payload", subscription.Channel.Payload)
+	}
+
+	if !isBackportSubscription(subscription) {
+		d.Set("criteria", subscription.Criteria)
+		return diags
+	}
+
+	var filterCriteria []string
+	if subscription.CriteriaElement != nil {
+		for _, extension := range subscription.CriteriaElement.Extension {
+			if extension.Url == smilecdr.BackportFilterCriteriaExtension {
+				filterCriteria = append(filterCriteria, extension.ValueString)
+			}
+		}
+	}
+	content := ""
+	if subscription.Channel != nil && subscription.Channel.PayloadElement != nil {
+		for _, extension := range subscription.Channel.PayloadElement.Extension {
+			if extension.Url == smilecdr.BackportPayloadContentExtension {
+				content = extension.ValueCode
+			}
+		}
+	}
+	d.Set("topic", subscription.Criteria)
+	d.Set("filter_criteria", filterCriteria)
+	d.Set("content", content)
+
+	return diags
+}
+
+func resourceSubscriptionUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	fhir, err := subscriptionFhirClient(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	subscription := resourceDataToSubscription(d)
+
+	_, err = fhir.PutSubscription(subscription)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if d.Get("enabled").(bool) {
+		if err := waitForSubscriptionActive(ctx, fhir, d.Id(), d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceSubscriptionRead(ctx, d, m)
+}
+
+func resourceSubscriptionDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	fhir, err := subscriptionFhirClient(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := fhir.DeleteSubscription(d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
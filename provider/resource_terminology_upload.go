@@ -0,0 +1,185 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zed-werks/terraform-smilecdr/provider/util"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func resourceTerminologyUpload() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceTerminologyUploadCreate,
+		ReadContext:   resourceTerminologyUploadRead,
+		UpdateContext: resourceTerminologyUploadUpdate,
+		DeleteContext: resourceTerminologyUploadDelete,
+		CustomizeDiff: resourceTerminologyUploadCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"system": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"files": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+			},
+			"content_hash": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"job_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"code_system_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(4 * time.Hour),
+			Update: schema.DefaultTimeout(4 * time.Hour),
+		},
+	}
+}
+
+func terminologyFiles(list []interface{}) []string {
+	files := make([]string, 0, len(list))
+	for _, file := range list {
+		files = append(files, file.(string))
+	}
+	return files
+}
+
+// resourceTerminologyUploadCustomizeDiff plans a re-upload whenever the content of
+// the local files no longer matches what was uploaded.
+func resourceTerminologyUploadCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("files") {
+		return d.SetNewComputed("content_hash")
+	}
+
+	hash, err := util.HashFiles(terminologyFiles(d.Get("files").([]interface{})))
+	if err != nil {
+		return fmt.Errorf("files: %s", err)
+	}
+	if d.Get("content_hash").(string) == hash {
+		return nil
+	}
+	if err := d.SetNew("content_hash", hash); err != nil {
+		return err
+	}
+	return d.SetNewComputed("job_id")
+}
+
+func uploadTerminology(ctx context.Context, d *schema.ResourceData, c *smilecdr.Client, timeout time.Duration) error {
+	fhir, err := c.Fhir()
+	if err != nil {
+		return err
+	}
+
+	files := terminologyFiles(d.Get("files").([]interface{}))
+	hash, err := util.HashFiles(files)
+	if err != nil {
+		return err
+	}
+
+	outcome, err := fhir.UploadExternalCodeSystem(d.Get("system").(string), files)
+	if err != nil {
+		return err
+	}
+
+	// small uploads are loaded before the operation returns, large ones by a batch job
+	d.Set("job_id", outcome.JobId)
+	if outcome.JobId != "" {
+		if err := waitForBatchJob(ctx, c, outcome.JobId, timeout); err != nil {
+			return err
+		}
+	}
+
+	d.Set("content_hash", hash)
+
+	return nil
+}
+
+func resourceTerminologyUploadCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	if err := uploadTerminology(ctx, d, c, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(d.Get("system").(string))
+
+	return resourceTerminologyUploadRead(ctx, d, m)
+}
+
+func resourceTerminologyUploadRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+	fhir, err := c.Fhir()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	codeSystemId, err := fhir.FindCodeSystem(d.Get("system").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if codeSystemId == "" {
+		d.SetId("")
+		return diags
+	}
+
+	d.Set("code_system_id", codeSystemId)
+
+	return diags
+}
+
+func resourceTerminologyUploadUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c, diags := clientFrom(m)
+	if diags.HasError() {
+		return diags
+	}
+
+	if d.HasChange("content_hash") {
+		if err := uploadTerminology(ctx, d, c, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceTerminologyUploadRead(ctx, d, m)
+}
+
+func resourceTerminologyUploadDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	// the code system stays loaded, removing a large terminology takes as long as
+	// loading it and stored data may still use its codes
+	d.SetId("")
+
+	return diags
+}
@@ -0,0 +1,56 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Contains reports whether value is one of values.
+func Contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// HashContent returns the hex encoded SHA-256 of content, used to detect drift
+// without keeping whole files in state.
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// HashFiles returns a hash over the names and contents of files, in order, so that
+// renaming, reordering or editing any of them changes the hash.
+func HashFiles(paths []string) (string, error) {
+	digest := sha256.New()
+	for _, path := range paths {
+		sum, err := hashFile(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(digest, "%s\x00%s\n", filepath.Base(path), sum)
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// hashFile returns the hex encoded SHA-256 of a file, read as a stream.
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	digest := sha256.New()
+	if _, err := io.Copy(digest, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
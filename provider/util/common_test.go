@@ -0,0 +1,30 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_HashFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.csv")
+	b := filepath.Join(dir, "b.csv")
+	os.WriteFile(a, []byte("code,display\n"), 0600)
+	os.WriteFile(b, []byte("parent,child\n"), 0600)
+
+	hash, err := HashFiles([]string{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reordered, _ := HashFiles([]string{b, a}); reordered == hash {
+		t.Fatal("expected reordering the files to change the hash")
+	}
+	os.WriteFile(b, []byte("parent,child\nA,B\n"), 0600)
+	if edited, _ := HashFiles([]string{a, b}); edited == hash {
+		t.Fatal("expected editing a file to change the hash")
+	}
+	if _, err := HashFiles([]string{filepath.Join(dir, "missing.csv")}); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
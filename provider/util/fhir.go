@@ -0,0 +1,123 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// NormalizeFhirResource strips the server managed id, meta.versionId,
+// meta.lastUpdated and meta.source from a FHIR resource and re-serialises it with sorted members,
+// so that resources can be compared semantically.
+func NormalizeFhirResource(text string) (string, error) {
+	var resource map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &resource); err != nil {
+		return "", err
+	}
+
+	delete(resource, "id")
+	if meta, ok := resource["meta"].(map[string]interface{}); ok {
+		delete(meta, "versionId")
+		delete(meta, "lastUpdated")
+		delete(meta, "source")
+		if len(meta) == 0 {
+			delete(resource, "meta")
+		}
+	}
+
+	out, err := json.Marshal(resource)
+	return string(out), err
+}
+
+// FhirResourceWithId sets the id of a FHIR resource, as required in the body of an update.
+func FhirResourceWithId(text string, id string) ([]byte, error) {
+	var resource map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &resource); err != nil {
+		return nil, err
+	}
+	resource["id"] = id
+	return json.Marshal(resource)
+}
+
+// FhirResourceType returns the resourceType of a FHIR resource.
+func FhirResourceType(text string) (string, error) {
+	var resource struct {
+		ResourceType string `json:"resourceType"`
+	}
+	if err := json.Unmarshal([]byte(text), &resource); err != nil {
+		return "", err
+	}
+	if resource.ResourceType == "" {
+		return "", fmt.Errorf("resource has no resourceType")
+	}
+	return resource.ResourceType, nil
+}
+
+var searchCriteriaPattern = regexp.MustCompile(`^[A-Z][A-Za-z]*(\?.*)?$`)
+
+// CheckSearchCriteria verifies that criteria is a FHIR search URL relative to the
+// base, e.g. "Observation?code=http://loinc.org|1975-2".
+func CheckSearchCriteria(criteria string) error {
+	if !searchCriteriaPattern.MatchString(criteria) {
+		return fmt.Errorf("%q is not of the form ResourceType?parameter=value", criteria)
+	}
+	_, query, _ := strings.Cut(criteria, "?")
+	if query == "" {
+		return nil
+	}
+	for _, pair := range strings.Split(query, "&") {
+		name, _, found := strings.Cut(pair, "=")
+		if !found || name == "" {
+			return fmt.Errorf("%q has a malformed search parameter %q", criteria, pair)
+		}
+		if _, err := url.QueryUnescape(pair); err != nil {
+			return fmt.Errorf("%q has a malformed search parameter %q: %s", criteria, pair, err)
+		}
+	}
+	return nil
+}
+
+// ValidateSearchCriteria is a schema validator wrapping CheckSearchCriteria.
+func ValidateSearchCriteria(v interface{}, k string) (ws []string, es []error) {
+	var errs []error
+	var warns []string
+	value, ok := v.(string)
+	if !ok {
+		errs = append(errs, fmt.Errorf("expected %s to be string", k))
+		return warns, errs
+	}
+	if err := CheckSearchCriteria(value); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %s", k, err))
+	}
+	return warns, errs
+}
+
+// FhirReferences returns every Reference.reference value found in a FHIR resource.
+func FhirReferences(resource []byte) []string {
+	var value interface{}
+	if err := json.Unmarshal(resource, &value); err != nil {
+		return nil
+	}
+	var references []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			for key, child := range node {
+				if reference, ok := child.(string); ok && key == "reference" {
+					references = append(references, reference)
+					continue
+				}
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+	walk(value)
+	return references
+}
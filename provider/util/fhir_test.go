@@ -0,0 +1,40 @@
+package util
+
+import (
+	"testing"
+)
+
+func Test_NormalizeFhirResource(t *testing.T) {
+	local := `{"resourceType":"Patient","active":true,"name":[{"family":"Smith"}]}`
+	server := `{
+  "resourceType": "Patient",
+  "id": "123",
+  "meta": {"versionId": "2", "lastUpdated": "2023-01-01T00:00:00Z", "source": "#abc"},
+  "name": [{"family": "Smith"}],
+  "active": true
+}`
+	a, err := NormalizeFhirResource(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NormalizeFhirResource(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Fatalf("expected %s to equal %s", a, b)
+	}
+}
+
+func Test_CheckSearchCriteria(t *testing.T) {
+	for _, criteria := range []string{"Patient", "Observation?code=http://loinc.org|1975-2", "Encounter?patient=Patient/1&status=finished"} {
+		if err := CheckSearchCriteria(criteria); err != nil {
+			t.Errorf("%s: unexpected error: %s", criteria, err)
+		}
+	}
+	for _, criteria := range []string{"", "patient?name=x", "/Patient?name=x", "Patient?name", "Patient?name=%zz"} {
+		if err := CheckSearchCriteria(criteria); err == nil {
+			t.Errorf("%s: expected an error", criteria)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// fhirPathOperators are the binary operators of FHIRPath, by precedence group.
+var fhirPathOperators = []string{
+	"*", "/", "div", "mod",
+	"+", "-", "&",
+	"|",
+	"<=", "<", ">", ">=",
+	"=", "~", "!=", "!~",
+	"in", "contains",
+	"and",
+	"or", "xor",
+	"implies",
+}
+
+// fhirPathTypeOperators are followed by a type specifier rather than an expression.
+var fhirPathTypeOperators = []string{"is", "as"}
+
+// fhirPathCalendarUnits may follow a number to form a quantity, e.g. "4 days".
+var fhirPathCalendarUnits = []string{
+	"year", "month", "week", "day", "hour", "minute", "second", "millisecond",
+	"years", "months", "weeks", "days", "hours", "minutes", "seconds", "milliseconds",
+}
+
+const (
+	fhirPathEOF = iota
+	fhirPathIdentifier
+	fhirPathDelimitedIdentifier
+	fhirPathString
+	fhirPathNumber
+	fhirPathDateTime
+	fhirPathConstant
+	fhirPathSymbol
+)
+
+type fhirPathToken struct {
+	kind int
+	text string
+	pos  int
+}
+
+func (t fhirPathToken) String() string {
+	if t.kind == fhirPathEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func (t fhirPathToken) is(text string) bool {
+	return (t.kind == fhirPathSymbol || t.kind == fhirPathIdentifier) && t.text == text
+}
+
+// tokenizeFhirPath splits a FHIRPath expression into tokens, dropping whitespace and comments.
+func tokenizeFhirPath(expr string) ([]fhirPathToken, error) {
+	var tokens []fhirPathToken
+	runes := []rune(expr)
+	i := 0
+
+	// quoted reads a ' or ` delimited literal starting at runes[i], honouring escapes
+	quoted := func(quote rune) (string, error) {
+		start := i
+		for i++; i < len(runes); i++ {
+			switch runes[i] {
+			case '\\':
+				i++
+			case quote:
+				i++
+				return string(runes[start:i]), nil
+			}
+		}
+		return "", fmt.Errorf("unterminated %c at position %d", quote, start)
+	}
+
+	for i < len(runes) {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			for i += 2; i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/'); i++ {
+			}
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("unterminated comment at position %d", start)
+			}
+			i += 2
+		case r == '\'':
+			text, err := quoted('\'')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, fhirPathToken{fhirPathString, text, start})
+		case r == '`':
+			text, err := quoted('`')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, fhirPathToken{fhirPathDelimitedIdentifier, text, start})
+		case r == '%':
+			i++
+			if i < len(runes) && (runes[i] == '\'' || runes[i] == '`') {
+				if _, err := quoted(runes[i]); err != nil {
+					return nil, err
+				}
+			} else {
+				for i < len(runes) && isFhirPathIdentifierRune(runes[i]) {
+					i++
+				}
+			}
+			if i == start+1 {
+				return nil, fmt.Errorf("expected a constant name after %% at position %d", start)
+			}
+			tokens = append(tokens, fhirPathToken{fhirPathConstant, string(runes[start:i]), start})
+		case r == '@':
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune("-:.TZ+", runes[i])) {
+				i++
+			}
+			if i == start+1 {
+				return nil, fmt.Errorf("expected a date or time after @ at position %d", start)
+			}
+			tokens = append(tokens, fhirPathToken{fhirPathDateTime, string(runes[start:i]), start})
+		case unicode.IsDigit(r):
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			if i+1 < len(runes) && runes[i] == '.' && unicode.IsDigit(runes[i+1]) {
+				for i++; i < len(runes) && unicode.IsDigit(runes[i]); i++ {
+				}
+			}
+			tokens = append(tokens, fhirPathToken{fhirPathNumber, string(runes[start:i]), start})
+		case r == '$' || isFhirPathIdentifierRune(r):
+			for i++; i < len(runes) && isFhirPathIdentifierRune(runes[i]); i++ {
+			}
+			tokens = append(tokens, fhirPathToken{fhirPathIdentifier, string(runes[start:i]), start})
+		default:
+			symbol := string(r)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "<=", ">=", "!=", "!~":
+					symbol = two
+				}
+			}
+			if !strings.ContainsAny(symbol, "().[]{},*/+-&|<>=~!") || symbol == "!" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, start)
+			}
+			i += len([]rune(symbol))
+			tokens = append(tokens, fhirPathToken{fhirPathSymbol, symbol, start})
+		}
+	}
+
+	return append(tokens, fhirPathToken{kind: fhirPathEOF, pos: len(runes)}), nil
+}
+
+func isFhirPathIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// fhirPathParser is a recursive descent recogniser for the FHIRPath grammar. It
+// only checks syntax, it does not resolve element names or function signatures.
+type fhirPathParser struct {
+	tokens []fhirPathToken
+	next   int
+}
+
+func (p *fhirPathParser) peek() fhirPathToken {
+	return p.tokens[p.next]
+}
+
+func (p *fhirPathParser) advance() fhirPathToken {
+	token := p.tokens[p.next]
+	if token.kind != fhirPathEOF {
+		p.next++
+	}
+	return token
+}
+
+func (p *fhirPathParser) expect(symbol string) error {
+	if token := p.advance(); !token.is(symbol) {
+		return fmt.Errorf("expected %q but found %s at position %d", symbol, token, token.pos)
+	}
+	return nil
+}
+
+func (p *fhirPathParser) expression() error {
+	if err := p.term(); err != nil {
+		return err
+	}
+	for {
+		token := p.peek()
+		switch {
+		case token.kind == fhirPathSymbol && Contains(fhirPathOperators, token.text),
+			token.kind == fhirPathIdentifier && Contains(fhirPathOperators, token.text):
+			p.advance()
+			if err := p.term(); err != nil {
+				return err
+			}
+		case token.kind == fhirPathIdentifier && Contains(fhirPathTypeOperators, token.text):
+			p.advance()
+			if err := p.typeSpecifier(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *fhirPathParser) typeSpecifier() error {
+	for {
+		token := p.advance()
+		if token.kind != fhirPathIdentifier && token.kind != fhirPathDelimitedIdentifier {
+			return fmt.Errorf("expected a type name but found %s at position %d", token, token.pos)
+		}
+		if !p.peek().is(".") {
+			return nil
+		}
+		p.advance()
+	}
+}
+
+func (p *fhirPathParser) term() error {
+	if token := p.peek(); token.is("+") || token.is("-") {
+		p.advance()
+		return p.term()
+	}
+	if err := p.primary(); err != nil {
+		return err
+	}
+	for {
+		switch token := p.peek(); {
+		case token.is("."):
+			p.advance()
+			if err := p.invocation(); err != nil {
+				return err
+			}
+		case token.is("["):
+			p.advance()
+			if err := p.expression(); err != nil {
+				return err
+			}
+			if err := p.expect("]"); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *fhirPathParser) primary() error {
+	token := p.peek()
+	switch token.kind {
+	case fhirPathString, fhirPathDateTime, fhirPathConstant:
+		p.advance()
+		return nil
+	case fhirPathNumber:
+		p.advance()
+		// a quantity has a UCUM unit string or a calendar duration after the number
+		if unit := p.peek(); unit.kind == fhirPathString ||
+			(unit.kind == fhirPathIdentifier && Contains(fhirPathCalendarUnits, unit.text)) {
+			p.advance()
+		}
+		return nil
+	case fhirPathIdentifier, fhirPathDelimitedIdentifier:
+		if token.kind == fhirPathIdentifier && Contains(fhirPathOperators, token.text) && !p.tokens[p.next+1].is("(") {
+			return fmt.Errorf("unexpected %s at position %d", token, token.pos)
+		}
+		return p.invocation()
+	case fhirPathSymbol:
+		switch token.text {
+		case "(":
+			p.advance()
+			if err := p.expression(); err != nil {
+				return err
+			}
+			return p.expect(")")
+		case "{":
+			p.advance()
+			return p.expect("}")
+		}
+	}
+	return fmt.Errorf("unexpected %s at position %d", token, token.pos)
+}
+
+// invocation is a member name or a function call, optionally with arguments.
+func (p *fhirPathParser) invocation() error {
+	token := p.advance()
+	if token.kind != fhirPathIdentifier && token.kind != fhirPathDelimitedIdentifier {
+		return fmt.Errorf("expected a name but found %s at position %d", token, token.pos)
+	}
+	if token.kind != fhirPathIdentifier || !p.peek().is("(") {
+		return nil
+	}
+	p.advance()
+	if p.peek().is(")") {
+		p.advance()
+		return nil
+	}
+	for {
+		if err := p.expression(); err != nil {
+			return err
+		}
+		if !p.peek().is(",") {
+			return p.expect(")")
+		}
+		p.advance()
+	}
+}
+
+// CheckFhirPath parses a FHIRPath expression and returns the first syntax error.
+func CheckFhirPath(expr string) error {
+	tokens, err := tokenizeFhirPath(expr)
+	if err != nil {
+		return err
+	}
+	parser := &fhirPathParser{tokens: tokens}
+	if parser.peek().kind == fhirPathEOF {
+		return fmt.Errorf("expression is empty")
+	}
+	if err := parser.expression(); err != nil {
+		return err
+	}
+	if token := parser.peek(); token.kind != fhirPathEOF {
+		return fmt.Errorf("unexpected %s at position %d", token, token.pos)
+	}
+	return nil
+}
+
+// ValidateFhirPath is a schema validator for attributes holding FHIRPath expressions.
+func ValidateFhirPath(v interface{}, k string) (ws []string, es []error) {
+	var errs []error
+	var warns []string
+	value, ok := v.(string)
+	if !ok {
+		errs = append(errs, fmt.Errorf("expected %s to be string", k))
+		return warns, errs
+	}
+	if err := CheckFhirPath(value); err != nil {
+		errs = append(errs, fmt.Errorf("invalid FHIRPath in %s: %s", k, err))
+	}
+	return warns, errs
+}
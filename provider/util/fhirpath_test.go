@@ -0,0 +1,44 @@
+package util
+
+import (
+	"testing"
+)
+
+func Test_CheckFhirPath(t *testing.T) {
+	valid := []string{
+		"Patient.name.family",
+		"Patient.extension('http://example.org/fhir/StructureDefinition/eye-colour').value",
+		"Patient.extension.where(url = 'http://example.org/eye-colour').value.as(CodeableConcept)",
+		"(Observation.value as Quantity) | (Observation.value as SampledData)",
+		"Observation.subject.where(resolve() is Patient)",
+		"Bundle.entry[0].resource as Composition",
+		"Patient.birthDate < @2000-01-01 and Patient.active = true",
+		"Observation.value.ofType(Quantity).value > 5 'mg' // trailing comment",
+		"%resource.`class`.code != {}",
+		"Encounter.period.start + 4 days",
+		"-Observation.value.value",
+	}
+	for _, expr := range valid {
+		if err := CheckFhirPath(expr); err != nil {
+			t.Errorf("%s: unexpected error: %s", expr, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"Patient.name.",
+		"Patient.name.where(use = 'official'",
+		"Patient.name.family)",
+		"Patient.name and",
+		"Patient..name",
+		"Observation.value as",
+		"Patient.name['x'",
+		"Patient.name = 'unterminated",
+		"Patient.name ! 'x'",
+	}
+	for _, expr := range invalid {
+		if err := CheckFhirPath(expr); err == nil {
+			t.Errorf("%s: expected a syntax error", expr)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+)
+
+// SigningAlgorithms lists the JWS algorithms supported for token signing keys.
+var SigningAlgorithms = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+var signingCurves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(),
+	"ES384": elliptic.P384(),
+	"ES512": elliptic.P521(),
+}
+
+type JSONWebKey struct {
+	Kty string   `json:"kty"`
+	Kid string   `json:"kid,omitempty"`
+	Use string   `json:"use,omitempty"`
+	Alg string   `json:"alg,omitempty"`
+	N   string   `json:"n,omitempty"`
+	E   string   `json:"e,omitempty"`
+	Crv string   `json:"crv,omitempty"`
+	X   string   `json:"x,omitempty"`
+	Y   string   `json:"y,omitempty"`
+	D   string   `json:"d,omitempty"`
+	P   string   `json:"p,omitempty"`
+	Q   string   `json:"q,omitempty"`
+	Dp  string   `json:"dp,omitempty"`
+	Dq  string   `json:"dq,omitempty"`
+	Qi  string   `json:"qi,omitempty"`
+	K   string   `json:"k,omitempty"`
+	X5c []string `json:"x5c,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// GenerateSigningKey creates a new private key suitable for alg.
+func GenerateSigningKey(alg string, rsaBits int) (crypto.Signer, error) {
+	switch alg {
+	case "RS256", "RS384", "RS512":
+		return rsa.GenerateKey(rand.Reader, rsaBits)
+	case "ES256", "ES384", "ES512":
+		return ecdsa.GenerateKey(signingCurves[alg], rand.Reader)
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm %s", alg)
+}
+
+// ParsePrivateKeyPEM reads a PKCS#8, PKCS#1 or SEC 1 encoded private key and checks it suits alg.
+func ParsePrivateKeyPEM(data string, alg string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if _, ok := signingCurves[alg]; ok {
+			return nil, fmt.Errorf("algorithm %s needs an EC key, got RSA", alg)
+		}
+		return k, nil
+	case *ecdsa.PrivateKey:
+		if curve, ok := signingCurves[alg]; !ok || curve != k.Curve {
+			return nil, fmt.Errorf("algorithm %s does not match EC curve %s", alg, k.Curve.Params().Name)
+		}
+		return k, nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", key)
+}
+
+// EncodePrivateKeyPEM encodes key as a PKCS#8 PEM block.
+func EncodePrivateKeyPEM(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// PrivateJWK encodes a private signing key, including its private parameters.
+func PrivateJWK(key crypto.Signer, kid string, alg string) (JSONWebKey, error) {
+	jwk, err := PublicJWK(key.Public(), kid, alg)
+	if err != nil {
+		return jwk, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		k.Precompute()
+		jwk.D = encodeBigInt(k.D)
+		jwk.P = encodeBigInt(k.Primes[0])
+		jwk.Q = encodeBigInt(k.Primes[1])
+		jwk.Dp = encodeBigInt(k.Precomputed.Dp)
+		jwk.Dq = encodeBigInt(k.Precomputed.Dq)
+		jwk.Qi = encodeBigInt(k.Precomputed.Qinv)
+	case *ecdsa.PrivateKey:
+		jwk.D = encodeFixed(k.D, curveBytes(k.Curve))
+	}
+	return jwk, nil
+}
+
+// PublicJWK encodes the public half of a signing key.
+func PublicJWK(key crypto.PublicKey, kid string, alg string) (JSONWebKey, error) {
+	jwk := JSONWebKey{Kid: kid, Use: "sig", Alg: alg}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeBigInt(k.N)
+		jwk.E = encodeBigInt(big.NewInt(int64(k.E)))
+	case *ecdsa.PublicKey:
+		size := curveBytes(k.Curve)
+		jwk.Kty = "EC"
+		jwk.Crv = k.Curve.Params().Name
+		jwk.X = encodeFixed(k.X, size)
+		jwk.Y = encodeFixed(k.Y, size)
+	default:
+		return jwk, fmt.Errorf("unsupported public key type %T", key)
+	}
+	return jwk, nil
+}
+
+// PrivateKey decodes the private signing key of a JWK written by PrivateJWK.
+func (k JSONWebKey) PrivateKey() (crypto.Signer, error) {
+	if k.D == "" {
+		return nil, fmt.Errorf("key %s has no private parameters", k.Kid)
+	}
+
+	switch k.Kty {
+	case "RSA":
+		values, err := decodeBigInts(k.N, k.E, k.D, k.P, k.Q)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %s", k.Kid, err)
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: values[0], E: int(values[1].Int64())},
+			D:         values[2],
+			Primes:    []*big.Int{values[3], values[4]},
+		}
+		if err := key.Validate(); err != nil {
+			return nil, fmt.Errorf("key %s: %s", k.Kid, err)
+		}
+		key.Precompute()
+		return key, nil
+	case "EC":
+		var curve elliptic.Curve
+		for _, c := range signingCurves {
+			if c.Params().Name == k.Crv {
+				curve = c
+			}
+		}
+		if curve == nil {
+			return nil, fmt.Errorf("key %s has unsupported curve %q", k.Kid, k.Crv)
+		}
+		values, err := decodeBigInts(k.X, k.Y, k.D)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %s", k.Kid, err)
+		}
+		if !curve.IsOnCurve(values[0], values[1]) {
+			return nil, fmt.Errorf("key %s: point is not on curve %s", k.Kid, k.Crv)
+		}
+		return &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{Curve: curve, X: values[0], Y: values[1]},
+			D:         values[2],
+		}, nil
+	}
+	return nil, fmt.Errorf("key %s has unsupported key type %q", k.Kid, k.Kty)
+}
+
+// Public returns a copy of the key with all private parameters removed.
+func (k JSONWebKey) Public() JSONWebKey {
+	k.D, k.P, k.Q, k.Dp, k.Dq, k.Qi, k.K = "", "", "", "", "", "", ""
+	return k
+}
+
+func curveBytes(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func encodeFixed(i *big.Int, size int) string {
+	return base64.RawURLEncoding.EncodeToString(i.FillBytes(make([]byte, size)))
+}
+
+func decodeBigInts(values ...string) ([]*big.Int, error) {
+	decoded := make([]*big.Int, len(values))
+	for i, value := range values {
+		if value == "" {
+			return nil, fmt.Errorf("missing key parameter")
+		}
+		b, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+		decoded[i] = new(big.Int).SetBytes(b)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"testing"
+)
+
+func Test_SigningKeyRoundTrip(t *testing.T) {
+	for _, alg := range SigningAlgorithms {
+		key, err := GenerateSigningKey(alg, 2048)
+		if err != nil {
+			t.Fatalf("%s: unexpected error generating key: %s", alg, err)
+		}
+		pem, err := EncodePrivateKeyPEM(key)
+		if err != nil {
+			t.Fatalf("%s: unexpected error encoding key: %s", alg, err)
+		}
+		parsed, err := ParsePrivateKeyPEM(pem, alg)
+		if err != nil {
+			t.Fatalf("%s: unexpected error parsing key: %s", alg, err)
+		}
+		jwk, err := PrivateJWK(parsed, "kid-1", alg)
+		if err != nil {
+			t.Fatalf("%s: unexpected error encoding JWK: %s", alg, err)
+		}
+		if jwk.D == "" || jwk.Public().D != "" {
+			t.Fatalf("%s: expected private parameters only on the private JWK", alg)
+		}
+		decoded, err := jwk.PrivateKey()
+		if err != nil {
+			t.Fatalf("%s: unexpected error decoding JWK: %s", alg, err)
+		}
+		if again, _ := PrivateJWK(decoded, "kid-1", alg); again.D != jwk.D || again.X != jwk.X || again.N != jwk.N {
+			t.Fatalf("%s: JWK round trip mismatch", alg)
+		}
+	}
+}
+
+func Test_ParsePrivateKeyPEMAlgorithmMismatch(t *testing.T) {
+	key, _ := GenerateSigningKey("ES256", 0)
+	pem, _ := EncodePrivateKeyPEM(key)
+
+	if _, err := ParsePrivateKeyPEM(pem, "RS256"); err == nil {
+		t.Fatal("expected an error for an EC key used with RS256")
+	}
+	if _, err := ParsePrivateKeyPEM(pem, "ES384"); err == nil {
+		t.Fatal("expected an error for a P-256 key used with ES384")
+	}
+}
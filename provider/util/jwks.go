@@ -0,0 +1,138 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// supportedJwkAlgorithms maps each supported key type to the algorithms it may declare.
+var supportedJwkAlgorithms = map[string][]string{
+	"RSA": {"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"},
+	"EC":  {"ES256", "ES384", "ES512"},
+}
+
+var supportedJwkCurves = map[string]string{
+	"P-256": "ES256",
+	"P-384": "ES384",
+	"P-521": "ES512",
+}
+
+// privateJwkMembers are the JWK members that carry private or symmetric key material.
+var privateJwkMembers = []string{"d", "p", "q", "dp", "dq", "qi", "oth", "k"}
+
+// CheckPublicJwks verifies that text is a JWK set of public signature keys only:
+// every key has a unique kid, a supported kty/alg and no private key material.
+func CheckPublicJwks(text string) []error {
+	var errs []error
+
+	var raw struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := json.Unmarshal([]byte(text), &raw); err != nil {
+		return append(errs, fmt.Errorf("JWKS is not valid JSON: %s", err))
+	}
+	var set JSONWebKeySet
+	if err := json.Unmarshal([]byte(text), &set); err != nil {
+		return append(errs, fmt.Errorf("JWKS does not contain valid JSON Web Keys: %s", err))
+	}
+	if len(set.Keys) == 0 {
+		return append(errs, fmt.Errorf("JWKS must contain at least one key"))
+	}
+
+	kids := make(map[string]bool)
+	for i, key := range set.Keys {
+		name := fmt.Sprintf("key %d", i)
+		if key.Kid == "" {
+			errs = append(errs, fmt.Errorf("%s has no kid", name))
+		} else {
+			name = fmt.Sprintf("key %q", key.Kid)
+			if kids[key.Kid] {
+				errs = append(errs, fmt.Errorf("kid %q is used by more than one key", key.Kid))
+			}
+			kids[key.Kid] = true
+		}
+
+		for _, member := range privateJwkMembers {
+			if _, ok := raw.Keys[i][member]; ok {
+				errs = append(errs, fmt.Errorf("%s contains private key material (%q), only public keys may be registered", name, member))
+			}
+		}
+
+		algorithms, ok := supportedJwkAlgorithms[key.Kty]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s has unsupported kty %q", name, key.Kty))
+			continue
+		}
+		if key.Alg != "" && !Contains(algorithms, key.Alg) {
+			errs = append(errs, fmt.Errorf("%s has kty %s and unsupported alg %q", name, key.Kty, key.Alg))
+		}
+		if key.Use != "" && key.Use != "sig" {
+			errs = append(errs, fmt.Errorf("%s has use %q, expected \"sig\"", name, key.Use))
+		}
+
+		switch key.Kty {
+		case "RSA":
+			if key.N == "" || key.E == "" {
+				errs = append(errs, fmt.Errorf("%s is an RSA key without n and e", name))
+			}
+		case "EC":
+			alg, ok := supportedJwkCurves[key.Crv]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s has unsupported crv %q", name, key.Crv))
+			} else if key.Alg != "" && key.Alg != alg {
+				errs = append(errs, fmt.Errorf("%s has crv %s, which is used with %s not %s", name, key.Crv, alg, key.Alg))
+			}
+			if key.X == "" || key.Y == "" {
+				errs = append(errs, fmt.Errorf("%s is an EC key without x and y", name))
+			}
+		}
+	}
+
+	return errs
+}
+
+// ValidatePublicJwks is a schema validator wrapping CheckPublicJwks.
+func ValidatePublicJwks(v interface{}, k string) (ws []string, es []error) {
+	var warns []string
+	value, ok := v.(string)
+	if !ok {
+		return warns, []error{fmt.Errorf("expected %s to be string", k)}
+	}
+	var errs []error
+	for _, err := range CheckPublicJwks(value) {
+		errs = append(errs, fmt.Errorf("%s: %s", k, err))
+	}
+	return warns, errs
+}
+
+// NormalizeJwks orders the keys of a JWK set by kid and serialises it compactly
+// with sorted members, so reordering keys or members does not produce a diff.
+// Text that does not parse is returned unchanged for the validator to report.
+func NormalizeJwks(text string) string {
+	if text == "" {
+		return ""
+	}
+	var set map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &set); err != nil {
+		return text
+	}
+	if keys, ok := set["keys"].([]interface{}); ok {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return jwkKid(keys[i]) < jwkKid(keys[j])
+		})
+	}
+	out, err := json.Marshal(set)
+	if err != nil {
+		return text
+	}
+	return string(out)
+}
+
+func jwkKid(key interface{}) string {
+	if m, ok := key.(map[string]interface{}); ok {
+		kid, _ := m["kid"].(string)
+		return kid
+	}
+	return ""
+}
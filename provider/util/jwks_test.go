@@ -0,0 +1,42 @@
+package util
+
+import (
+	"testing"
+)
+
+const testRsaJwk = `{"kty":"RSA","kid":"a","alg":"RS384","use":"sig","n":"sXch","e":"AQAB"}`
+const testEcJwk = `{"kty":"EC","kid":"b","alg":"ES384","crv":"P-384","x":"eA","y":"eQ"}`
+
+func Test_CheckPublicJwks(t *testing.T) {
+	cases := map[string]struct {
+		jwks   string
+		errors int
+	}{
+		"valid":          {`{"keys":[` + testRsaJwk + `,` + testEcJwk + `]}`, 0},
+		"not json":       {`{"keys":[`, 1},
+		"empty":          {`{"keys":[]}`, 1},
+		"duplicate kid":  {`{"keys":[` + testRsaJwk + `,` + testRsaJwk + `]}`, 1},
+		"missing kid":    {`{"keys":[{"kty":"RSA","n":"sXch","e":"AQAB"}]}`, 1},
+		"private key":    {`{"keys":[{"kty":"RSA","kid":"a","n":"sXch","e":"AQAB","d":"c2Vj"}]}`, 1},
+		"symmetric key":  {`{"keys":[{"kty":"oct","kid":"a","k":"c2Vj"}]}`, 2},
+		"wrong alg":      {`{"keys":[{"kty":"RSA","kid":"a","alg":"ES256","n":"sXch","e":"AQAB"}]}`, 1},
+		"curve mismatch": {`{"keys":[{"kty":"EC","kid":"b","alg":"ES256","crv":"P-384","x":"eA","y":"eQ"}]}`, 1},
+	}
+
+	for name, tc := range cases {
+		if errs := CheckPublicJwks(tc.jwks); len(errs) != tc.errors {
+			t.Errorf("%s: expected %d errors, got %d: %v", name, tc.errors, len(errs), errs)
+		}
+	}
+}
+
+func Test_NormalizeJwks(t *testing.T) {
+	a := NormalizeJwks(`{"keys":[` + testEcJwk + `,` + testRsaJwk + `]}`)
+	b := NormalizeJwks(`{ "keys": [` + testRsaJwk + `, ` + testEcJwk + `] }`)
+	if a != b {
+		t.Fatalf("expected reordered key sets to normalise identically:\n%s\n%s", a, b)
+	}
+	if NormalizeJwks("not json") != "not json" {
+		t.Fatal("expected invalid JSON to be returned unchanged")
+	}
+}
@@ -0,0 +1,277 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MdmMatchers are the phonetic and exact matcher algorithms of an MDM match field.
+var MdmMatchers = []string{
+	"CAVERPHONE1", "CAVERPHONE2", "COLOGNE", "DOUBLE_METAPHONE", "MATCH_RATING_APPROACH",
+	"METAPHONE", "NYSIIS", "REFINED_SOUNDEX", "SOUNDEX", "NICKNAME", "STRING", "SUBSTRING",
+	"DATE", "NAME_ANY_ORDER", "NAME_FIRST_AND_LAST", "IDENTIFIER", "EMPTY_FIELD",
+	"EXTENSION_ANY_ORDER", "NUMERIC",
+}
+
+// MdmSimilarities are the similarity algorithms of an MDM match field.
+var MdmSimilarities = []string{
+	"JARO_WINKLER", "COSINE", "JACCARD", "LEVENSCHTEIN", "NORMALIZED_LEVENSCHTEIN", "SORENSEN_DICE",
+}
+
+// MdmMatchResults are the values of the matchResultMap.
+var MdmMatchResults = []string{"MATCH", "POSSIBLE_MATCH", "NO_MATCH"}
+
+type MdmCandidateSearchParam struct {
+	ResourceType string   `json:"resourceType"`
+	SearchParams []string `json:"searchParams"`
+}
+
+type MdmCandidateFilterSearchParam struct {
+	ResourceType string `json:"resourceType"`
+	SearchParam  string `json:"searchParam"`
+	FixedValue   string `json:"fixedValue"`
+}
+
+type MdmMatcher struct {
+	Algorithm        string `json:"algorithm"`
+	IdentifierSystem string `json:"identifierSystem,omitempty"`
+	Exact            bool   `json:"exact,omitempty"`
+}
+
+type MdmSimilarity struct {
+	Algorithm      string   `json:"algorithm"`
+	MatchThreshold *float64 `json:"matchThreshold,omitempty"`
+	Exact          bool     `json:"exact,omitempty"`
+}
+
+type MdmMatchField struct {
+	Name         string         `json:"name"`
+	ResourceType string         `json:"resourceType"`
+	ResourcePath string         `json:"resourcePath,omitempty"`
+	FhirPath     string         `json:"fhirPath,omitempty"`
+	Matcher      *MdmMatcher    `json:"matcher,omitempty"`
+	Similarity   *MdmSimilarity `json:"similarity,omitempty"`
+}
+
+// MdmRules models the MDM rules JSON document of a Smile CDR MDM module.
+type MdmRules struct {
+	Version                     string                          `json:"version"`
+	MdmTypes                    []string                        `json:"mdmTypes"`
+	CandidateSearchParams       []MdmCandidateSearchParam       `json:"candidateSearchParams"`
+	CandidateFilterSearchParams []MdmCandidateFilterSearchParam `json:"candidateFilterSearchParams,omitempty"`
+	MatchFields                 []MdmMatchField                 `json:"matchFields"`
+	MatchResultMap              map[string]string               `json:"matchResultMap"`
+	EidSystem                   string                          `json:"eidSystem,omitempty"`
+	EidSystems                  map[string]string               `json:"eidSystems,omitempty"`
+}
+
+// ParseMdmRules reads an MDM rules document. Members the model does not know are
+// ignored, UnknownMdmMembers lists them.
+func ParseMdmRules(text string) (MdmRules, error) {
+	var rules MdmRules
+	if err := json.Unmarshal([]byte(text), &rules); err != nil {
+		return rules, fmt.Errorf("MDM rules do not match the rules format: %s", err)
+	}
+	return rules, nil
+}
+
+// UnknownMdmMembers returns the paths of the members of an MDM rules document that
+// the model does not know, e.g. "matchFields[0].weight". Newer Smile CDR releases
+// add members, so these are worth a warning but not an error.
+func UnknownMdmMembers(text string) []string {
+	var document interface{}
+	if err := json.Unmarshal([]byte(text), &document); err != nil {
+		return nil
+	}
+	var unknown []string
+	collectUnknownMembers(document, reflect.TypeOf(MdmRules{}), "", &unknown)
+	sort.Strings(unknown)
+	return unknown
+}
+
+func collectUnknownMembers(value interface{}, t reflect.Type, path string, unknown *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if t.Kind() == reflect.Map {
+			for key, member := range v {
+				collectUnknownMembers(member, t.Elem(), path+"."+key, unknown)
+			}
+			return
+		}
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			fields[name] = t.Field(i).Type
+		}
+		for key, member := range v {
+			memberPath := strings.TrimPrefix(path+"."+key, ".")
+			fieldType, ok := fields[key]
+			if !ok {
+				*unknown = append(*unknown, memberPath)
+				continue
+			}
+			collectUnknownMembers(member, fieldType, memberPath, unknown)
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice {
+			return
+		}
+		for i, item := range v {
+			collectUnknownMembers(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), unknown)
+		}
+	}
+}
+
+// CheckMdmRules verifies an MDM rules document the way the MDM module does when it
+// starts, so broken rules are reported at plan time rather than as a failed module.
+func CheckMdmRules(text string) []error {
+	var errs []error
+
+	rules, err := ParseMdmRules(text)
+	if err != nil {
+		return append(errs, err)
+	}
+
+	if len(rules.MdmTypes) == 0 {
+		errs = append(errs, fmt.Errorf("mdmTypes must list at least one resource type"))
+	}
+	isMdmType := func(resourceType string) bool {
+		return resourceType == "*" || Contains(rules.MdmTypes, resourceType)
+	}
+
+	if len(rules.CandidateSearchParams) == 0 {
+		errs = append(errs, fmt.Errorf("candidateSearchParams must have at least one entry"))
+	}
+	for i, candidate := range rules.CandidateSearchParams {
+		if !isMdmType(candidate.ResourceType) {
+			errs = append(errs, fmt.Errorf("candidateSearchParams[%d] has resourceType %q, which is not one of the mdmTypes", i, candidate.ResourceType))
+		}
+		if len(candidate.SearchParams) == 0 {
+			errs = append(errs, fmt.Errorf("candidateSearchParams[%d] has no searchParams", i))
+		}
+	}
+	for i, filter := range rules.CandidateFilterSearchParams {
+		if !isMdmType(filter.ResourceType) {
+			errs = append(errs, fmt.Errorf("candidateFilterSearchParams[%d] has resourceType %q, which is not one of the mdmTypes", i, filter.ResourceType))
+		}
+		if filter.SearchParam == "" {
+			errs = append(errs, fmt.Errorf("candidateFilterSearchParams[%d] has no searchParam", i))
+		}
+	}
+
+	fields := make(map[string]bool)
+	for i, field := range rules.MatchFields {
+		name := field.Name
+		if name == "" {
+			errs = append(errs, fmt.Errorf("matchFields[%d] has no name", i))
+			name = fmt.Sprintf("matchFields[%d]", i)
+		} else if fields[name] {
+			errs = append(errs, fmt.Errorf("match field %q is defined more than once", name))
+		}
+		fields[field.Name] = true
+
+		if !isMdmType(field.ResourceType) {
+			errs = append(errs, fmt.Errorf("match field %q has resourceType %q, which is not one of the mdmTypes", name, field.ResourceType))
+		}
+		if (field.ResourcePath == "") == (field.FhirPath == "") {
+			errs = append(errs, fmt.Errorf("match field %q needs exactly one of resourcePath and fhirPath", name))
+		}
+		if field.FhirPath != "" {
+			if err := CheckFhirPath(field.FhirPath); err != nil {
+				errs = append(errs, fmt.Errorf("match field %q has an invalid fhirPath: %s", name, err))
+			}
+		}
+
+		switch {
+		case (field.Matcher == nil) == (field.Similarity == nil):
+			errs = append(errs, fmt.Errorf("match field %q needs exactly one of matcher and similarity", name))
+		case field.Matcher != nil:
+			if !Contains(MdmMatchers, field.Matcher.Algorithm) {
+				errs = append(errs, fmt.Errorf("match field %q has unknown matcher algorithm %q", name, field.Matcher.Algorithm))
+			}
+			if field.Matcher.IdentifierSystem != "" && field.Matcher.Algorithm != "IDENTIFIER" {
+				errs = append(errs, fmt.Errorf("match field %q sets identifierSystem, which only applies to the IDENTIFIER matcher", name))
+			}
+		case field.Similarity != nil:
+			if !Contains(MdmSimilarities, field.Similarity.Algorithm) {
+				errs = append(errs, fmt.Errorf("match field %q has unknown similarity algorithm %q", name, field.Similarity.Algorithm))
+			}
+			if threshold := field.Similarity.MatchThreshold; threshold == nil {
+				errs = append(errs, fmt.Errorf("match field %q uses a similarity and needs a matchThreshold", name))
+			} else if *threshold < 0 || *threshold > 1 {
+				errs = append(errs, fmt.Errorf("match field %q has matchThreshold %v, expected a value between 0 and 1", name, *threshold))
+			}
+		}
+	}
+
+	if len(rules.MatchResultMap) == 0 {
+		errs = append(errs, fmt.Errorf("matchResultMap must have at least one entry"))
+	}
+	for key, result := range rules.MatchResultMap {
+		for _, fieldName := range strings.Split(key, ",") {
+			if !fields[strings.TrimSpace(fieldName)] {
+				errs = append(errs, fmt.Errorf("matchResultMap key %q references undefined match field %q", key, strings.TrimSpace(fieldName)))
+			}
+		}
+		if !Contains(MdmMatchResults, result) {
+			errs = append(errs, fmt.Errorf("matchResultMap key %q has result %q, expected one of %s", key, result, strings.Join(MdmMatchResults, ", ")))
+		}
+	}
+
+	if rules.EidSystem != "" && len(rules.EidSystems) > 0 {
+		errs = append(errs, fmt.Errorf("set either eidSystem or eidSystems, not both"))
+	}
+	if rules.EidSystem != "" {
+		if err := checkUri(rules.EidSystem); err != nil {
+			errs = append(errs, fmt.Errorf("eidSystem: %s", err))
+		}
+	}
+	for resourceType, system := range rules.EidSystems {
+		if !isMdmType(resourceType) {
+			errs = append(errs, fmt.Errorf("eidSystems has resourceType %q, which is not one of the mdmTypes", resourceType))
+		}
+		if err := checkUri(system); err != nil {
+			errs = append(errs, fmt.Errorf("eidSystems[%s]: %s", resourceType, err))
+		}
+	}
+
+	return errs
+}
+
+func checkUri(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme == "" {
+		return fmt.Errorf("%q is not an absolute URI", value)
+	}
+	return nil
+}
+
+// ValidateMdmRules is a schema validator wrapping CheckMdmRules.
+func ValidateMdmRules(v interface{}, k string) (ws []string, es []error) {
+	var warns []string
+	value, ok := v.(string)
+	if !ok {
+		return warns, []error{fmt.Errorf("expected %s to be string", k)}
+	}
+	var errs []error
+	for _, err := range CheckMdmRules(value) {
+		errs = append(errs, fmt.Errorf("%s: %s", k, err))
+	}
+	for _, member := range UnknownMdmMembers(value) {
+		warns = append(warns, fmt.Sprintf("%s: %s is not a known MDM rules member and is not checked", k, member))
+	}
+	return warns, errs
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+const testMdmRules = `{
+	"version": "1",
+	"mdmTypes": ["Patient"],
+	"candidateSearchParams": [{"resourceType": "Patient", "searchParams": ["birthdate"]}],
+	"matchFields": [
+		{"name": "given-name", "resourceType": "Patient", "resourcePath": "name.given", "similarity": {"algorithm": "JARO_WINKLER", "matchThreshold": 0.8}},
+		{"name": "birthday", "resourceType": "Patient", "fhirPath": "birthDate", "matcher": {"algorithm": "STRING"}}
+	],
+	"matchResultMap": {"given-name": "POSSIBLE_MATCH", "given-name,birthday": "MATCH"},
+	"eidSystem": "http://example.org/eid"
+}`
+
+func Test_CheckMdmRules(t *testing.T) {
+	if errs := CheckMdmRules(testMdmRules); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	broken := strings.NewReplacer(
+		`"given-name,birthday": "MATCH"`, `"given-name,surname": "MATCH"`,
+		`"matchThreshold": 0.8`, `"matchThreshold": 1.8`,
+		`"eidSystem": "http://example.org/eid"`, `"eidSystem": "not a uri"`,
+	).Replace(testMdmRules)
+	errs := CheckMdmRules(broken)
+	if len(errs) != 3 {
+		t.Fatalf("expected three errors, got %v", errs)
+	}
+
+}
+
+func Test_ValidateMdmRulesWarnsOnUnknownMembers(t *testing.T) {
+	rules := strings.Replace(testMdmRules, `"version": "1",`, `"version": "1", "futureMember": true,`, 1)
+	rules = strings.Replace(rules, `"fhirPath": "birthDate",`, `"fhirPath": "birthDate", "weight": 2,`, 1)
+
+	warns, errs := ValidateMdmRules(rules, "rules_json")
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(warns) != 2 || !strings.Contains(warns[0], "futureMember") || !strings.Contains(warns[1], "matchFields[1].weight") {
+		t.Fatalf("expected warnings for both unknown members, got %v", warns)
+	}
+}
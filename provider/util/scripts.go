@@ -0,0 +1,31 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/dop251/goja/parser"
+)
+
+// CheckJavaScript parses a Smile CDR callback script and returns the first syntax error.
+func CheckJavaScript(name string, script string) error {
+	_, err := parser.ParseFile(nil, name, script, 0)
+	if err != nil {
+		return fmt.Errorf("invalid JavaScript in %s: %s", name, err)
+	}
+	return nil
+}
+
+// ValidateJavaScript is a schema validator for attributes holding callback scripts.
+func ValidateJavaScript(v interface{}, k string) (ws []string, es []error) {
+	var errs []error
+	var warns []string
+	value, ok := v.(string)
+	if !ok {
+		errs = append(errs, fmt.Errorf("expected %s to be string", k))
+		return warns, errs
+	}
+	if err := CheckJavaScript(k, value); err != nil {
+		errs = append(errs, err)
+	}
+	return warns, errs
+}
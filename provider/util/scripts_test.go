@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+)
+
+func Test_CheckJavaScript(t *testing.T) {
+	valid := `function onAuthenticateSuccess(theOutcome, theOutcomeFactory, theContext) {
+	theOutcome.addAuthority('FHIR_ALL_READ');
+	return theOutcome;
+}`
+	if err := CheckJavaScript("valid.js", valid); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	invalid := `function onAuthenticateSuccess(theOutcome {
+	return theOutcome;
+}`
+	if err := CheckJavaScript("invalid.js", invalid); err == nil {
+		t.Fatal("expected a syntax error")
+	}
+}
+
+func Test_ValidateJavaScript(t *testing.T) {
+	if _, errs := ValidateJavaScript("var x = ;", "script"); len(errs) != 1 {
+		t.Fatalf("expected one error, got %d", len(errs))
+	}
+	if _, errs := ValidateJavaScript(42, "script"); len(errs) != 1 {
+		t.Fatalf("expected one error for a non-string, got %d", len(errs))
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+// versionConstraint limits an attribute to a range of Smile CDR releases, e.g.
+// {MinVersion: "2023.05"}. Both bounds are inclusive and either may be empty.
+type versionConstraint struct {
+	MinVersion string
+	MaxVersion string
+}
+
+func mustParseVersion(text string) smilecdr.Version {
+	if text == "" {
+		return smilecdr.Version{}
+	}
+	version, err := smilecdr.ParseVersion(text)
+	if err != nil {
+		panic(err)
+	}
+	return version
+}
+
+// checkVersionConstraints returns a CustomizeDiffFunc rejecting attributes that the
+// server version does not support. The version is only detected once an attribute
+// with a constraint is set, and the plan fails when it cannot be detected.
+func checkVersionConstraints(constraints map[string]versionConstraint) schema.CustomizeDiffFunc {
+	type bounds struct {
+		min smilecdr.Version
+		max smilecdr.Version
+	}
+	parsed := make(map[string]bounds, len(constraints))
+	attributes := make([]string, 0, len(constraints))
+	for attribute, constraint := range constraints {
+		parsed[attribute] = bounds{min: mustParseVersion(constraint.MinVersion), max: mustParseVersion(constraint.MaxVersion)}
+		attributes = append(attributes, attribute)
+	}
+	sort.Strings(attributes)
+
+	return func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+		c, ok := m.(*smilecdr.Client)
+		if !ok {
+			return nil
+		}
+
+		for _, attribute := range attributes {
+			if _, set := d.GetOk(attribute); !set {
+				continue
+			}
+			version, err := c.Version()
+			if err != nil {
+				return fmt.Errorf("%s needs a Smile CDR release check, but the server version could not be detected: %s", attribute, err)
+			}
+			b := parsed[attribute]
+			if !b.min.IsZero() && version.Compare(b.min) < 0 {
+				return fmt.Errorf("%s requires Smile CDR %s or later, the server runs %s", attribute, b.min, version)
+			}
+			if !b.max.IsZero() && version.Compare(b.max) > 0 {
+				return fmt.Errorf("%s is not supported after Smile CDR %s, the server runs %s", attribute, b.max, version)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zed-werks/terraform-smilecdr/smilecdr"
+)
+
+func Test_checkVersionConstraintsFailsWithoutVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := smilecdr.NewClient(server.URL, "admin", "password")
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"topic":        "http://example.org/topic/admission",
+		"channel_type": "rest-hook",
+		"endpoint":     "https://hooks.example.org",
+	})
+
+	_, err := resourceSubscription().Diff(context.Background(), nil, config, c)
+	if err == nil || !strings.Contains(err.Error(), "topic needs a Smile CDR release check") {
+		t.Fatalf("expected the gated attribute to be reported, got %v", err)
+	}
+}
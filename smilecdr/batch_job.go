@@ -0,0 +1,90 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Batch job statuses. ERRORED jobs are retried by the server, FAILED and
+// CANCELLED jobs are final.
+const (
+	BatchJobStatusQueued     = "QUEUED"
+	BatchJobStatusInProgress = "IN_PROGRESS"
+	BatchJobStatusFinalize   = "FINALIZE"
+	BatchJobStatusErrored    = "ERRORED"
+	BatchJobStatusCompleted  = "COMPLETED"
+	BatchJobStatusFailed     = "FAILED"
+	BatchJobStatusCancelled  = "CANCELLED"
+)
+
+type BatchJob struct {
+	InstanceId      string  `json:"instanceId"`
+	JobDefinitionId string  `json:"jobDefinitionId,omitempty"`
+	Status          string  `json:"status"`
+	Progress        float64 `json:"progress,omitempty"`
+	ErrorMessage    string  `json:"errorMessage,omitempty"`
+	ErrorCount      int     `json:"errorCount,omitempty"`
+	CreateTime      string  `json:"createTime,omitempty"`
+	StartTime       string  `json:"startTime,omitempty"`
+	EndTime         string  `json:"endTime,omitempty"`
+	Report          string  `json:"report,omitempty"`
+}
+
+func (smilecdr *Client) GetBatchJob(instanceId string) (BatchJob, error) {
+	var job BatchJob
+	var endpoint = fmt.Sprintf("/batch-job/%s", instanceId)
+	jsonBody, getErr := smilecdr.Get(endpoint)
+	if getErr != nil {
+		fmt.Println("error during Get in GetBatchJob:", getErr)
+		return job, getErr
+	}
+
+	err := json.Unmarshal(jsonBody, &job)
+	if err != nil {
+		fmt.Println("error parsing Get response JSON:", err)
+	}
+
+	return job, err
+}
+
+// IsFinal reports whether the job has stopped, successfully or not.
+func (job BatchJob) IsFinal() bool {
+	return job.Status == BatchJobStatusCompleted || job.Status == BatchJobStatusFailed || job.Status == BatchJobStatusCancelled
+}
+
+// StartJob invokes an operation that runs as a batch job, such as "$reindex",
+// "$mdm-submit" or "Patient/$export", and returns the job instance ID. Operations
+// that completed synchronously return an empty ID.
+func (fhir *FhirClient) StartJob(operation string, parameters Parameters) (string, error) {
+	if parameters.ResourceType == "" {
+		parameters.ResourceType = "Parameters"
+	}
+	body, err := json.Marshal(parameters)
+	if err != nil {
+		return "", err
+	}
+
+	body, header, err := fhir.send(http.MethodPost, operation, body, map[string]string{"Prefer": "respond-async"})
+	if err != nil {
+		fmt.Println("error during Operation in StartJob:", err)
+		return "", err
+	}
+
+	// bulk export answers 202 Accepted with a status URL carrying the job ID
+	if location := header.Get("Content-Location"); location != "" {
+		if statusUrl, err := url.Parse(location); err == nil && statusUrl.Query().Get("_jobId") != "" {
+			return statusUrl.Query().Get("_jobId"), nil
+		}
+	}
+
+	var response Parameters
+	if len(body) > 0 && json.Unmarshal(body, &response) == nil {
+		return response.String("jobId"), nil
+	}
+	return "", nil
+}
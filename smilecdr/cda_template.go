@@ -0,0 +1,70 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"fmt"
+)
+
+// CdaTemplate is a CDA Exchange+ document template: a Handlebars template and the
+// FHIR resource types it renders.
+type CdaTemplate struct {
+	Name          string
+	Template      string
+	ResourceTypes []string
+}
+
+func (template CdaTemplate) parameters() Parameters {
+	parameters := NewParameters(
+		ParametersParameter{Name: "name", ValueString: template.Name},
+		ParametersParameter{Name: "template", ValueString: template.Template},
+	)
+	for _, resourceType := range template.ResourceTypes {
+		parameters.Parameter = append(parameters.Parameter, ParametersParameter{Name: "resourceType", ValueCode: resourceType})
+	}
+	return parameters
+}
+
+func (fhir *FhirClient) GetCdaTemplate(name string) (CdaTemplate, error) {
+	var template CdaTemplate
+	parameters := NewParameters(ParametersParameter{Name: "name", ValueString: name})
+
+	response, err := fhir.InvokeOperation("$get-cda-template", parameters)
+	if err != nil {
+		fmt.Println("error during Operation in GetCdaTemplate:", err)
+		return template, err
+	}
+
+	template.Name = response.String("name")
+	template.Template = response.String("template")
+	template.ResourceTypes = response.Strings("resourceType")
+	return template, nil
+}
+
+func (fhir *FhirClient) PostCdaTemplate(template CdaTemplate) error {
+	_, err := fhir.InvokeOperation("$create-cda-template", template.parameters())
+	if err != nil {
+		fmt.Println("error during Operation in PostCdaTemplate:", err)
+	}
+	return err
+}
+
+// PutCdaTemplate overwrites the template and resource types of an existing template.
+func (fhir *FhirClient) PutCdaTemplate(template CdaTemplate) error {
+	_, err := fhir.InvokeOperation("$update-cda-template", template.parameters())
+	if err != nil {
+		fmt.Println("error during Operation in PutCdaTemplate:", err)
+	}
+	return err
+}
+
+func (fhir *FhirClient) DeleteCdaTemplate(name string) error {
+	parameters := NewParameters(ParametersParameter{Name: "name", ValueString: name})
+
+	_, err := fhir.InvokeOperation("$delete-cda-template", parameters)
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package smilecdr
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_CdaTemplateGetAndPut(t *testing.T) {
+	var updated Parameters
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/$get-cda-template":
+			w.Write([]byte(`{"resourceType":"Parameters","parameter":[{"name":"name","valueString":"summary"},{"name":"template","valueString":"{{title}}"},{"name":"resourceType","valueCode":"Patient"},{"name":"resourceType","valueCode":"Condition"}]}`))
+		case "/$update-cda-template":
+			json.NewDecoder(r.Body).Decode(&updated)
+			w.Write([]byte(`{"resourceType":"Parameters"}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fhir := NewFhirClient(server.URL, "admin", "password")
+	template, err := fhir.GetCdaTemplate("summary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if template.Template != "{{title}}" || len(template.ResourceTypes) != 2 || template.ResourceTypes[1] != "Condition" {
+		t.Fatalf("unexpected template %+v", template)
+	}
+
+	template.Template = "{{subject}}"
+	if err := fhir.PutCdaTemplate(template); err != nil {
+		t.Fatal(err)
+	}
+	if updated.String("template") != "{{subject}}" || len(updated.Strings("resourceType")) != 2 {
+		t.Fatalf("unexpected update %+v", updated)
+	}
+}
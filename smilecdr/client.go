@@ -9,12 +9,51 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sync"
 )
 
+// ApiError is returned when the Smile CDR Admin API responds with a non-200 status code.
+type ApiError struct {
+	StatusCode int
+	Endpoint   string
+	Body       string
+}
+
+func (e *ApiError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("smilecdr: received HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("smilecdr: received HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+func newApiError(resp *http.Response) *ApiError {
+	body, _ := ioutil.ReadAll(resp.Body)
+	return &ApiError{StatusCode: resp.StatusCode, Endpoint: resp.Request.URL.Path, Body: string(body)}
+}
+
+// IsNotFound reports whether err is an ApiError or FhirError for a 404 Not Found response.
+func IsNotFound(err error) bool {
+	switch e := err.(type) {
+	case *ApiError:
+		return e.StatusCode == http.StatusNotFound
+	case *FhirError:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
 type Client struct {
 	baseUrl    string
 	authHeader string
 	httpClient *http.Client
+	fhir       *FhirClient
+
+	versionOnce sync.Once
+	version     Version
+	versionErr  error
+
+	defaultNodeId   string
+	defaultModuleId string
 }
 
 func NewClient(baseUrl string, username string, password string) *Client {
@@ -28,6 +67,44 @@ func NewClient(baseUrl string, username string, password string) *Client {
 	}
 }
 
+// SetFhirClient attaches the client used for the FHIR REST endpoint.
+func (c *Client) SetFhirClient(fhir *FhirClient) {
+	c.fhir = fhir
+}
+
+// Fhir returns the FHIR REST endpoint client, or an error when none is configured.
+func (c *Client) Fhir() (*FhirClient, error) {
+	if c.fhir == nil {
+		return nil, fmt.Errorf("smilecdr: no FHIR endpoint is configured")
+	}
+	return c.fhir, nil
+}
+
+// SetDefaults records the node and module used when a configuration does not name one.
+func (c *Client) SetDefaults(nodeId string, moduleId string) {
+	c.defaultNodeId = nodeId
+	c.defaultModuleId = moduleId
+}
+
+// DefaultNodeId returns the node set by SetDefaults.
+func (c *Client) DefaultNodeId() string {
+	return c.defaultNodeId
+}
+
+// DefaultModuleId returns the module set by SetDefaults.
+func (c *Client) DefaultModuleId() string {
+	return c.defaultModuleId
+}
+
+// Version returns the server version, running DetectVersion the first time it is
+// needed. The result, or the error, is kept for the life of the client.
+func (c *Client) Version() (Version, error) {
+	c.versionOnce.Do(func() {
+		c.version, c.versionErr = c.DetectVersion()
+	})
+	return c.version, c.versionErr
+}
+
 func (c *Client) Get(endpoint string) ([]byte, error) {
 	url := c.baseUrl + endpoint
 	req, err := http.NewRequest(http.MethodGet, url, nil)
@@ -47,8 +124,7 @@ func (c *Client) Get(endpoint string) ([]byte, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		fmt.Println("received non-200 OK status code:", resp.StatusCode)
-		// Handle the error condition here
-		return nil, err
+		return nil, newApiError(resp)
 	}
 
 	body, err := ioutil.ReadAll(resp.Body)
@@ -80,8 +156,7 @@ func (c *Client) Post(endpoint string, body []byte) ([]byte, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		fmt.Println("received non-200 OK status code:", resp.StatusCode)
-		// Handle the error condition here
-		return nil, err
+		return nil, newApiError(resp)
 	}
 
 	body, err = ioutil.ReadAll(resp.Body)
@@ -112,8 +187,7 @@ func (c *Client) Put(endpoint string, body []byte) ([]byte, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		fmt.Println("received non-200 OK status code:", resp.StatusCode)
-		// Handle the error condition here
-		return nil, err
+		return nil, newApiError(resp)
 	}
 
 	body, err = ioutil.ReadAll(resp.Body)
@@ -144,8 +218,7 @@ func (c *Client) Delete(endpoint string) ([]byte, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		fmt.Println("received non-200 OK status code:", resp.StatusCode)
-		// Handle the error condition here
-		return nil, err
+		return nil, newApiError(resp)
 	}
 
 	body, err := ioutil.ReadAll(resp.Body)
@@ -0,0 +1,498 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const fhirContentType = "application/fhir+json"
+
+// FhirClient talks to a Smile CDR FHIR endpoint module, which listens on its own
+// base URL (port 8000 by default) and may use different credentials than the
+// JSON Admin API.
+type FhirClient struct {
+	baseUrl    string
+	authHeader string
+	httpClient *http.Client
+}
+
+func NewFhirClient(baseUrl string, username string, password string) *FhirClient {
+	credentials := username + ":" + password
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+
+	return &FhirClient{
+		baseUrl:    strings.TrimSuffix(baseUrl, "/"),
+		authHeader: auth,
+		httpClient: &http.Client{},
+	}
+}
+
+// ForPartition returns a client that routes requests to the named partition,
+// using Smile CDR's URL based request tenant partitioning.
+func (fhir *FhirClient) ForPartition(partitionName string) *FhirClient {
+	if partitionName == "" {
+		return fhir
+	}
+	partitioned := *fhir
+	partitioned.baseUrl = fhir.baseUrl + "/" + url.PathEscape(partitionName)
+	return &partitioned
+}
+
+// WithBaseUrl returns a client for another FHIR endpoint with the same credentials,
+// such as an R5 endpoint next to the default R4 one.
+func (fhir *FhirClient) WithBaseUrl(baseUrl string) *FhirClient {
+	if baseUrl == "" {
+		return fhir
+	}
+	other := *fhir
+	other.baseUrl = strings.TrimSuffix(baseUrl, "/")
+	return &other
+}
+
+// OperationOutcomeIssue is one issue of a FHIR OperationOutcome.
+type OperationOutcomeIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics,omitempty"`
+	Details     *struct {
+		Text string `json:"text,omitempty"`
+	} `json:"details,omitempty"`
+}
+
+// FhirError is returned when the FHIR endpoint responds with an error status. The
+// issues of the OperationOutcome in the response body, if any, are kept.
+type FhirError struct {
+	StatusCode int
+	Issues     []OperationOutcomeIssue
+	Body       string
+}
+
+func (e *FhirError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		message := issue.Diagnostics
+		if message == "" && issue.Details != nil {
+			message = issue.Details.Text
+		}
+		if message != "" {
+			messages = append(messages, fmt.Sprintf("%s: %s", issue.Severity, message))
+		}
+	}
+	if len(messages) == 0 {
+		if e.Body == "" {
+			return fmt.Sprintf("smilecdr: FHIR endpoint returned HTTP %d", e.StatusCode)
+		}
+		return fmt.Sprintf("smilecdr: FHIR endpoint returned HTTP %d: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("smilecdr: FHIR endpoint returned HTTP %d: %s", e.StatusCode, strings.Join(messages, "; "))
+}
+
+func newFhirError(resp *http.Response, body []byte) *FhirError {
+	fhirErr := &FhirError{StatusCode: resp.StatusCode, Body: string(body)}
+
+	var outcome struct {
+		ResourceType string                  `json:"resourceType"`
+		Issue        []OperationOutcomeIssue `json:"issue"`
+	}
+	if json.Unmarshal(body, &outcome) == nil && outcome.ResourceType == "OperationOutcome" {
+		fhirErr.Issues = outcome.Issue
+	}
+	return fhirErr
+}
+
+// IsGone reports whether err is a FhirError for a 410 Gone (deleted) resource.
+func IsGone(err error) bool {
+	fhirErr, ok := err.(*FhirError)
+	return ok && fhirErr.StatusCode == http.StatusGone
+}
+
+// Meta is the meta element of a FHIR resource.
+type Meta struct {
+	VersionId   string   `json:"versionId,omitempty"`
+	LastUpdated string   `json:"lastUpdated,omitempty"`
+	Profile     []string `json:"profile,omitempty"`
+}
+
+// ResourceMeta holds the identity and version of a FHIR resource.
+type ResourceMeta struct {
+	ResourceType string `json:"resourceType"`
+	Id           string `json:"id"`
+	Meta         Meta   `json:"meta"`
+}
+
+// ParseResourceMeta reads the identity and version out of a FHIR resource body.
+func ParseResourceMeta(body []byte) (ResourceMeta, error) {
+	var meta ResourceMeta
+	err := json.Unmarshal(body, &meta)
+	return meta, err
+}
+
+type BundleLink struct {
+	Relation string `json:"relation"`
+	Url      string `json:"url"`
+}
+
+type BundleEntryRequest struct {
+	Method      string `json:"method"`
+	Url         string `json:"url"`
+	IfNoneExist string `json:"ifNoneExist,omitempty"`
+	IfMatch     string `json:"ifMatch,omitempty"`
+}
+
+type BundleEntryResponse struct {
+	Status       string `json:"status"`
+	Location     string `json:"location,omitempty"`
+	Etag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// ParseLocation splits a response location such as "Patient/123/_history/2" into
+// the resource type, ID and version. Absolute URLs are accepted.
+func (r *BundleEntryResponse) ParseLocation() (string, string, string) {
+	parts := strings.Split(strings.Trim(r.Location, "/"), "/")
+	version := ""
+	if len(parts) >= 4 && parts[len(parts)-2] == "_history" {
+		version = parts[len(parts)-1]
+		parts = parts[:len(parts)-2]
+	}
+	if len(parts) < 2 {
+		return "", "", version
+	}
+	return parts[len(parts)-2], parts[len(parts)-1], version
+}
+
+type BundleEntry struct {
+	FullUrl  string               `json:"fullUrl,omitempty"`
+	Resource json.RawMessage      `json:"resource,omitempty"`
+	Request  *BundleEntryRequest  `json:"request,omitempty"`
+	Response *BundleEntryResponse `json:"response,omitempty"`
+}
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type"`
+	Total        int           `json:"total,omitempty"`
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+// ParametersParameter is one named parameter of a FHIR Parameters resource.
+type ParametersParameter struct {
+	Name         string                `json:"name"`
+	ValueString  string                `json:"valueString,omitempty"`
+	ValueCode    string                `json:"valueCode,omitempty"`
+	ValueUri     string                `json:"valueUri,omitempty"`
+	ValueBoolean *bool                 `json:"valueBoolean,omitempty"`
+	ValueInteger *int                  `json:"valueInteger,omitempty"`
+	Resource     json.RawMessage       `json:"resource,omitempty"`
+	Part         []ParametersParameter `json:"part,omitempty"`
+}
+
+// Parameters is the FHIR resource used for the input and output of operations.
+type Parameters struct {
+	ResourceType string                `json:"resourceType"`
+	Parameter    []ParametersParameter `json:"parameter,omitempty"`
+}
+
+func NewParameters(parameters ...ParametersParameter) Parameters {
+	return Parameters{ResourceType: "Parameters", Parameter: parameters}
+}
+
+// String returns the first string-like value of the named parameter.
+func (p Parameters) String(name string) string {
+	for _, parameter := range p.Parameter {
+		if parameter.Name != name {
+			continue
+		}
+		for _, value := range []string{parameter.ValueString, parameter.ValueCode, parameter.ValueUri} {
+			if value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// Strings returns the string-like values of every parameter with the given name.
+func (p Parameters) Strings(name string) []string {
+	var values []string
+	for _, parameter := range p.Parameter {
+		if parameter.Name != name {
+			continue
+		}
+		for _, value := range []string{parameter.ValueString, parameter.ValueCode, parameter.ValueUri} {
+			if value != "" {
+				values = append(values, value)
+				break
+			}
+		}
+	}
+	return values
+}
+
+// Int returns the integer value of the named parameter.
+func (p Parameters) Int(name string) (int, bool) {
+	for _, parameter := range p.Parameter {
+		if parameter.Name == name && parameter.ValueInteger != nil {
+			return *parameter.ValueInteger, true
+		}
+	}
+	return 0, false
+}
+
+func (b *Bundle) nextLink() string {
+	for _, link := range b.Link {
+		if link.Relation == "next" {
+			return link.Url
+		}
+	}
+	return ""
+}
+
+// do sends a request to the FHIR endpoint. endpoint is either a path relative to
+// the base URL or an absolute URL, such as a paging link returned by the server.
+func (fhir *FhirClient) do(method string, endpoint string, body []byte, headers map[string]string) ([]byte, error) {
+	respBody, _, err := fhir.send(method, endpoint, body, headers)
+	return respBody, err
+}
+
+// send is do, also returning the response headers.
+func (fhir *FhirClient) send(method string, endpoint string, body []byte, headers map[string]string) ([]byte, http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	return fhir.sendReader(method, endpoint, reader, headers)
+}
+
+// sendReader is send with a streamed request body.
+func (fhir *FhirClient) sendReader(method string, endpoint string, body io.Reader, headers map[string]string) ([]byte, http.Header, error) {
+	target := endpoint
+	if endpoint == "" {
+		target = fhir.baseUrl
+	} else if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		target = fhir.baseUrl + "/" + strings.TrimPrefix(endpoint, "/")
+	}
+
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Add("Authorization", fhir.authHeader)
+	req.Header.Add("Accept", fhirContentType)
+	if body != nil {
+		req.Header.Add("Content-Type", fhirContentType)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := fhir.httpClient.Do(req)
+	if err != nil {
+		fmt.Printf("error making FHIR %s Request: %s\n", method, err)
+		return nil, nil, err
+	}
+
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("error reading FHIR %s Response Body: %s\n", method, err)
+		return nil, nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, resp.Header, newFhirError(resp, respBody)
+	}
+
+	return respBody, resp.Header, nil
+}
+
+// Read fetches the current version of a resource.
+func (fhir *FhirClient) Read(resourceType string, id string) ([]byte, error) {
+	return fhir.do(http.MethodGet, resourceType+"/"+url.PathEscape(id), nil, nil)
+}
+
+// Create posts a new resource and lets the server assign its ID.
+func (fhir *FhirClient) Create(resourceType string, resource []byte) ([]byte, error) {
+	return fhir.do(http.MethodPost, resourceType, resource, map[string]string{"Prefer": "return=representation"})
+}
+
+// Update puts a resource at the given ID, creating it if the server allows client
+// assigned IDs. When versionId is set the update is conditional on it (If-Match).
+func (fhir *FhirClient) Update(resourceType string, id string, resource []byte, versionId string) ([]byte, error) {
+	headers := map[string]string{"Prefer": "return=representation"}
+	if versionId != "" {
+		headers["If-Match"] = fmt.Sprintf("W/\"%s\"", versionId)
+	}
+	return fhir.do(http.MethodPut, resourceType+"/"+url.PathEscape(id), resource, headers)
+}
+
+// Delete removes a resource. Deleting a resource that is already gone is not an error.
+func (fhir *FhirClient) Delete(resourceType string, id string) error {
+	_, err := fhir.do(http.MethodDelete, resourceType+"/"+url.PathEscape(id), nil, nil)
+	if IsNotFound(err) || IsGone(err) {
+		return nil
+	}
+	return err
+}
+
+// Search runs a search and follows the paging links, returning every matching entry.
+func (fhir *FhirClient) Search(resourceType string, params url.Values) ([]BundleEntry, error) {
+	entries := make([]BundleEntry, 0)
+	endpoint := resourceType
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	for endpoint != "" {
+		body, err := fhir.do(http.MethodGet, endpoint, nil, nil)
+		if err != nil {
+			return entries, err
+		}
+		var bundle Bundle
+		if err := json.Unmarshal(body, &bundle); err != nil {
+			return entries, err
+		}
+		entries = append(entries, bundle.Entry...)
+		endpoint = bundle.nextLink()
+	}
+
+	return entries, nil
+}
+
+// Count returns the number of resources of a type matching params, using a
+// _summary=count search. An empty resourceType searches every type at once. A
+// response without a total is an error, so callers never mistake an unknown count
+// for zero.
+func (fhir *FhirClient) Count(resourceType string, params url.Values) (int, error) {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("_summary", "count")
+
+	body, err := fhir.do(http.MethodGet, resourceType+"?"+query.Encode(), nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	var bundle struct {
+		Total *int `json:"total"`
+	}
+	if err := json.Unmarshal(body, &bundle); err != nil {
+		return 0, err
+	}
+	if bundle.Total == nil {
+		return 0, fmt.Errorf("smilecdr: the count response for %q has no total", resourceType)
+	}
+	return *bundle.Total, nil
+}
+
+// Transaction posts a transaction (or batch) Bundle and returns the response Bundle.
+func (fhir *FhirClient) Transaction(bundle Bundle) (Bundle, error) {
+	var response Bundle
+	body, err := json.Marshal(bundle)
+	if err != nil {
+		return response, err
+	}
+
+	body, err = fhir.do(http.MethodPost, "", body, nil)
+	if err != nil {
+		return response, err
+	}
+
+	err = json.Unmarshal(body, &response)
+	return response, err
+}
+
+// Operation invokes an extended operation such as "$reindex". path is relative to
+// the base URL, e.g. "$reindex" or "Patient/$validate".
+func (fhir *FhirClient) Operation(path string, parameters []byte) ([]byte, error) {
+	if parameters == nil {
+		parameters = []byte(`{"resourceType":"Parameters"}`)
+	}
+	return fhir.do(http.MethodPost, path, parameters, nil)
+}
+
+// InvokeOperation invokes an extended operation with typed Parameters and parses the
+// Parameters it returns.
+func (fhir *FhirClient) InvokeOperation(path string, parameters Parameters) (Parameters, error) {
+	var response Parameters
+	body, err := json.Marshal(parameters)
+	if err != nil {
+		return response, err
+	}
+
+	body, err = fhir.Operation(path, body)
+	if err != nil {
+		return response, err
+	}
+
+	err = json.Unmarshal(body, &response)
+	return response, err
+}
+
+// MultipartFile is a file sent as one part of a multipart/form-data request. The
+// file at Filename is read while the request is sent.
+type MultipartFile struct {
+	Field    string
+	Filename string
+}
+
+// Upload posts form fields and files as multipart/form-data, as the terminology
+// loader operations accept for large code system distributions. The body is
+// streamed, so files are never held in memory.
+func (fhir *FhirClient) Upload(path string, fields map[string]string, files []MultipartFile) ([]byte, error) {
+	reader, pipe := io.Pipe()
+	writer := multipart.NewWriter(pipe)
+	go func() {
+		pipe.CloseWithError(writeMultipart(writer, fields, files))
+	}()
+
+	body, _, err := fhir.sendReader(http.MethodPost, path, reader, map[string]string{"Content-Type": writer.FormDataContentType()})
+	// stops the writer when the request ended before reading the whole body
+	reader.Close()
+	return body, err
+}
+
+func writeMultipart(writer *multipart.Writer, fields map[string]string, files []MultipartFile) error {
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		if err := writeMultipartFile(writer, file); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func writeMultipartFile(writer *multipart.Writer, file MultipartFile) error {
+	content, err := os.Open(file.Filename)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	part, err := writer.CreateFormFile(file.Field, filepath.Base(file.Filename))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, content)
+	return err
+}
@@ -0,0 +1,182 @@
+package smilecdr
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_FhirClientOperationOutcome(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		w.Write([]byte(`{"resourceType":"OperationOutcome","issue":[{"severity":"error","code":"conflict","diagnostics":"version conflict"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := NewFhirClient(server.URL, "admin", "password").Update("Patient", "p1", []byte(`{}`), "3")
+	fhirErr, ok := err.(*FhirError)
+	if !ok {
+		t.Fatalf("expected a FhirError, got %v", err)
+	}
+	if fhirErr.StatusCode != http.StatusPreconditionFailed || !strings.Contains(err.Error(), "version conflict") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func Test_FhirClientUpdateIfMatch(t *testing.T) {
+	var ifMatch, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifMatch = r.Header.Get("If-Match")
+		path = r.URL.Path
+		w.Write([]byte(`{"resourceType":"Patient","id":"p1","meta":{"versionId":"4"}}`))
+	}))
+	defer server.Close()
+
+	body, err := NewFhirClient(server.URL+"/", "admin", "password").Update("Patient", "p1", []byte(`{}`), "3")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ifMatch != `W/"3"` || path != "/Patient/p1" {
+		t.Fatalf("unexpected request: If-Match %q, path %q", ifMatch, path)
+	}
+	meta, _ := ParseResourceMeta(body)
+	if meta.Meta.VersionId != "4" {
+		t.Fatalf("expected version 4, got %q", meta.Meta.VersionId)
+	}
+}
+
+func Test_FhirClientSearchFollowsPages(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`{"resourceType":"Bundle","type":"searchset","entry":[{"fullUrl":"b"}]}`))
+			return
+		}
+		w.Write([]byte(`{"resourceType":"Bundle","type":"searchset","link":[{"relation":"next","url":"` + server.URL + `/Patient?page=2"}],"entry":[{"fullUrl":"a"}]}`))
+	}))
+	defer server.Close()
+
+	entries, err := NewFhirClient(server.URL, "admin", "password").Search("Patient", url.Values{"name": {"smith"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+}
+
+func Test_FhirClientPartitionOperations(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(`{"resourceType":"Parameters","parameter":[{"name":"id","valueInteger":2},{"name":"name","valueCode":"tenant-a"}]}`))
+	}))
+	defer server.Close()
+
+	partition, err := NewFhirClient(server.URL, "admin", "password").PostPartition(Partition{Id: 2, Name: "tenant-a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if path != "/DEFAULT/$partition-management-create-partition" {
+		t.Fatalf("unexpected path %q", path)
+	}
+	if partition.Id != 2 || partition.Name != "tenant-a" {
+		t.Fatalf("unexpected partition %+v", partition)
+	}
+}
+
+func Test_FhirClientUploadMultipart(t *testing.T) {
+	var system, filename, content string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("expected a multipart request: %s", err)
+		}
+		system = r.FormValue("system")
+		file, header, _ := r.FormFile("file")
+		filename = header.Filename
+		buf := new(strings.Builder)
+		io.Copy(buf, file)
+		content = buf.String()
+		w.Write([]byte(`{"resourceType":"Parameters","parameter":[{"name":"jobId","valueString":"job-1"}]}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "concepts.csv")
+	os.WriteFile(path, []byte("CODE,DISPLAY\nA,Alpha\n"), 0o600)
+
+	outcome, err := NewFhirClient(server.URL, "admin", "password").UploadExternalCodeSystem("http://example.org/cs", []string{path})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if system != "http://example.org/cs" || filename != "concepts.csv" || !strings.HasPrefix(content, "CODE,DISPLAY") {
+		t.Fatalf("unexpected upload: system %q, file %q, content %q", system, filename, content)
+	}
+	if outcome.JobId != "job-1" {
+		t.Fatalf("expected job-1, got %q", outcome.JobId)
+	}
+}
+
+func Test_FhirClientCountResources(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/tenant-a/" || r.URL.Query().Get("_summary") != "count" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`{"resourceType":"Bundle","type":"searchset","total":3}`))
+	}))
+	defer server.Close()
+
+	fhir := NewFhirClient(server.URL, "admin", "password").ForPartition("tenant-a")
+	count, err := fhir.CountResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || requests != 1 {
+		t.Fatalf("expected 3 resources from one request, got %d from %d", count, requests)
+	}
+}
+
+func Test_FhirClientCountWithoutTotal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"resourceType":"Bundle","type":"searchset"}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewFhirClient(server.URL, "admin", "password").CountResources(); err == nil {
+		t.Fatal("expected an error for a count without a total")
+	}
+}
+
+func Test_FhirClientUploadMissingFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		w.Write([]byte(`{"resourceType":"Parameters"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewFhirClient(server.URL, "admin", "password").UploadExternalCodeSystem("http://example.org/cs", []string{filepath.Join(t.TempDir(), "missing.zip")})
+	if err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
+
+func Test_FhirClientWithBaseUrl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fhir_r5/Subscription/1" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"resourceType":"Subscription","id":"1"}`))
+	}))
+	defer server.Close()
+
+	fhir := NewFhirClient(server.URL+"/fhir_request", "admin", "password").WithBaseUrl(server.URL + "/fhir_r5/")
+	if _, err := fhir.GetSubscription("1"); err != nil {
+		t.Fatal(err)
+	}
+}
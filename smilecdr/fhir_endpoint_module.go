@@ -0,0 +1,98 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Option keys used by the FHIR REST endpoint module.
+const (
+	fhirEndpointPort        = "port"
+	fhirEndpointBaseUrl     = "base_url.fixed"
+	fhirEndpointCorsEnabled = "cors.enable"
+	fhirEndpointCorsOrigins = "cors.origins"
+)
+
+type FhirEndpointModule struct {
+	NodeId             string
+	ModuleId           string
+	FhirVersion        string
+	Port               int
+	BaseUrl            string
+	CorsEnabled        bool
+	CorsAllowedOrigins []string
+	StorageModuleId    string
+	SecurityModuleIds  []string
+}
+
+// ModuleConfig maps the typed module onto the generic module-config representation.
+func (m FhirEndpointModule) ModuleConfig() ModuleConfig {
+	config := ModuleConfig{
+		NodeId:       m.NodeId,
+		ModuleId:     m.ModuleId,
+		ModuleType:   FhirEndpointModuleTypePrefix + m.FhirVersion,
+		Options:      make([]ModuleOption, 0),
+		Dependencies: make([]ModuleDependency, 0),
+	}
+
+	config.SetOption(fhirEndpointPort, strconv.Itoa(m.Port))
+	config.SetOption(fhirEndpointBaseUrl, m.BaseUrl)
+	config.SetOption(fhirEndpointCorsEnabled, strconv.FormatBool(m.CorsEnabled))
+	config.SetOption(fhirEndpointCorsOrigins, strings.Join(m.CorsAllowedOrigins, ","))
+
+	config.Dependencies = append(config.Dependencies, ModuleDependency{ModuleId: m.StorageModuleId, Type: DependencyTypePersistence})
+	for _, securityModuleId := range m.SecurityModuleIds {
+		config.Dependencies = append(config.Dependencies, ModuleDependency{ModuleId: securityModuleId, Type: DependencyTypeSecurityIn})
+	}
+
+	return config
+}
+
+// FhirEndpointModuleFromConfig reads the typed module back out of a module-config response.
+func FhirEndpointModuleFromConfig(config ModuleConfig) (FhirEndpointModule, error) {
+	var err error
+	module := FhirEndpointModule{
+		NodeId:             config.NodeId,
+		ModuleId:           config.ModuleId,
+		BaseUrl:            config.Option(fhirEndpointBaseUrl),
+		CorsAllowedOrigins: make([]string, 0),
+		SecurityModuleIds:  make([]string, 0),
+	}
+
+	if !strings.HasPrefix(config.ModuleType, FhirEndpointModuleTypePrefix) {
+		return module, fmt.Errorf("module %s has type %s, expected a %s* module", config.ModuleId, config.ModuleType, FhirEndpointModuleTypePrefix)
+	}
+	module.FhirVersion = strings.TrimPrefix(config.ModuleType, FhirEndpointModuleTypePrefix)
+
+	module.Port = optionInt(config, fhirEndpointPort, &err)
+	module.CorsEnabled = optionBool(config, fhirEndpointCorsEnabled, &err)
+	for _, origin := range strings.Split(config.Option(fhirEndpointCorsOrigins), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			module.CorsAllowedOrigins = append(module.CorsAllowedOrigins, origin)
+		}
+	}
+
+	for _, dependency := range config.Dependencies {
+		switch dependency.Type {
+		case DependencyTypePersistence:
+			module.StorageModuleId = dependency.ModuleId
+		case DependencyTypeSecurityIn:
+			module.SecurityModuleIds = append(module.SecurityModuleIds, dependency.ModuleId)
+		}
+	}
+
+	return module, err
+}
+
+// ListenerPort returns the port option of a module, or 0 when it has none.
+func (m *ModuleConfig) ListenerPort() int {
+	port, err := strconv.Atoi(m.Option(fhirEndpointPort))
+	if err != nil {
+		return 0
+	}
+	return port
+}
@@ -0,0 +1,126 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Option keys used by the FHIR Storage (RDBMS) persistence module.
+const (
+	fhirStorageDbDriver                  = "db.driver"
+	fhirStorageDbUrl                     = "db.url"
+	fhirStorageDbUsername                = "db.username"
+	fhirStorageDbPassword                = "db.password"
+	fhirStorageDbMaxConnections          = "db.connectionpool.maxtotal"
+	fhirStoragePartitioningEnabled       = "partitioning.enabled"
+	fhirStoragePartitioningRequestTenant = "partitioning.request_tenant_partitioning_mode"
+	fhirStoragePartitioningPatientId     = "partitioning.patient_id_partitioning_mode"
+	fhirStorageSearchCacheEnabled        = "dao_config.reuse_cached_search_results.enabled"
+	fhirStorageSearchCacheMillis         = "dao_config.reuse_cached_search_results_millis"
+	fhirStorageReindexEnabled            = "reindex.enabled"
+	fhirStorageExpungeEnabled            = "dao_config.expunge_enabled"
+	fhirStorageDeleteExpungeEnabled      = "dao_config.delete_expunge_enabled"
+)
+
+var (
+	FhirVersions            = []string{"DSTU2", "DSTU3", "R4", "R4B", "R5"}
+	FhirStorageDbDrivers    = []string{"DERBY_EMBEDDED", "H2_EMBEDDED", "MSSQL_2012", "MYSQL_5_7", "ORACLE_12C", "POSTGRES_9_4"}
+	FhirStoragePartitioning = []string{"NONE", "REQUEST_TENANT", "PATIENT_ID"}
+)
+
+type FhirStorageModule struct {
+	NodeId               string
+	ModuleId             string
+	FhirVersion          string
+	DbDriver             string
+	DbUrl                string
+	DbUsername           string
+	DbPassword           string
+	DbMaxConnections     int
+	PartitioningMode     string
+	SearchCacheEnabled   bool
+	SearchCacheMillis    int
+	ReindexEnabled       bool
+	ExpungeEnabled       bool
+	DeleteExpungeEnabled bool
+}
+
+// ModuleConfig maps the typed module onto the generic module-config representation.
+func (m FhirStorageModule) ModuleConfig() ModuleConfig {
+	config := ModuleConfig{
+		NodeId:     m.NodeId,
+		ModuleId:   m.ModuleId,
+		ModuleType: PersistenceModuleTypePrefix + m.FhirVersion,
+		Options:    make([]ModuleOption, 0),
+	}
+
+	config.SetOption(fhirStorageDbDriver, m.DbDriver)
+	config.SetOption(fhirStorageDbUrl, m.DbUrl)
+	config.SetOption(fhirStorageDbUsername, m.DbUsername)
+	if m.DbPassword != "" {
+		config.SetOption(fhirStorageDbPassword, m.DbPassword)
+	}
+	config.SetOption(fhirStorageDbMaxConnections, strconv.Itoa(m.DbMaxConnections))
+
+	config.SetOption(fhirStoragePartitioningEnabled, strconv.FormatBool(m.PartitioningMode != "" && m.PartitioningMode != "NONE"))
+	config.SetOption(fhirStoragePartitioningRequestTenant, strconv.FormatBool(m.PartitioningMode == "REQUEST_TENANT"))
+	config.SetOption(fhirStoragePartitioningPatientId, strconv.FormatBool(m.PartitioningMode == "PATIENT_ID"))
+
+	config.SetOption(fhirStorageSearchCacheEnabled, strconv.FormatBool(m.SearchCacheEnabled))
+	config.SetOption(fhirStorageSearchCacheMillis, strconv.Itoa(m.SearchCacheMillis))
+	config.SetOption(fhirStorageReindexEnabled, strconv.FormatBool(m.ReindexEnabled))
+	config.SetOption(fhirStorageExpungeEnabled, strconv.FormatBool(m.ExpungeEnabled))
+	config.SetOption(fhirStorageDeleteExpungeEnabled, strconv.FormatBool(m.DeleteExpungeEnabled))
+
+	return config
+}
+
+// ClearedOptions returns the keys of the optional settings that previous set and m
+// leaves out. ModuleConfig does not write them, so they must be removed instead.
+func (m FhirStorageModule) ClearedOptions(previous FhirStorageModule) []string {
+	var keys []string
+	if previous.DbPassword != "" && m.DbPassword == "" {
+		keys = append(keys, fhirStorageDbPassword)
+	}
+	return keys
+}
+
+// FhirStorageModuleFromConfig reads the typed module back out of a module-config response.
+func FhirStorageModuleFromConfig(config ModuleConfig) (FhirStorageModule, error) {
+	var err error
+	module := FhirStorageModule{
+		NodeId:     config.NodeId,
+		ModuleId:   config.ModuleId,
+		DbDriver:   config.Option(fhirStorageDbDriver),
+		DbUrl:      config.Option(fhirStorageDbUrl),
+		DbUsername: config.Option(fhirStorageDbUsername),
+		DbPassword: config.Option(fhirStorageDbPassword),
+	}
+
+	if !strings.HasPrefix(config.ModuleType, PersistenceModuleTypePrefix) {
+		return module, fmt.Errorf("module %s has type %s, expected a %s* module", config.ModuleId, config.ModuleType, PersistenceModuleTypePrefix)
+	}
+	module.FhirVersion = strings.TrimPrefix(config.ModuleType, PersistenceModuleTypePrefix)
+
+	switch {
+	case !optionBool(config, fhirStoragePartitioningEnabled, &err):
+		module.PartitioningMode = "NONE"
+	case optionBool(config, fhirStoragePartitioningPatientId, &err):
+		module.PartitioningMode = "PATIENT_ID"
+	default:
+		module.PartitioningMode = "REQUEST_TENANT"
+	}
+
+	module.DbMaxConnections = optionInt(config, fhirStorageDbMaxConnections, &err)
+	module.SearchCacheEnabled = optionBool(config, fhirStorageSearchCacheEnabled, &err)
+	module.SearchCacheMillis = optionInt(config, fhirStorageSearchCacheMillis, &err)
+	module.ReindexEnabled = optionBool(config, fhirStorageReindexEnabled, &err)
+	module.ExpungeEnabled = optionBool(config, fhirStorageExpungeEnabled, &err)
+	module.DeleteExpungeEnabled = optionBool(config, fhirStorageDeleteExpungeEnabled, &err)
+
+	return module, err
+}
@@ -0,0 +1,67 @@
+package smilecdr
+
+import (
+	"testing"
+)
+
+func Test_FhirStorageModuleRoundTrip(t *testing.T) {
+	module := FhirStorageModule{
+		NodeId:               "Master",
+		ModuleId:             "persistence",
+		FhirVersion:          "R4",
+		DbDriver:             "POSTGRES_9_4",
+		DbUrl:                "jdbc:postgresql://localhost:5432/cdr",
+		DbUsername:           "cdr",
+		DbPassword:           "secret",
+		DbMaxConnections:     50,
+		PartitioningMode:     "PATIENT_ID",
+		SearchCacheEnabled:   true,
+		SearchCacheMillis:    1000,
+		ReindexEnabled:       true,
+		ExpungeEnabled:       true,
+		DeleteExpungeEnabled: false,
+	}
+
+	config := module.ModuleConfig()
+	if config.ModuleType != "PERSISTENCE_R4" {
+		t.Fatalf("expected module type PERSISTENCE_R4, got %s", config.ModuleType)
+	}
+
+	parsed, err := FhirStorageModuleFromConfig(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if parsed != module {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", parsed, module)
+	}
+}
+
+func Test_FhirStorageModuleFromConfigRejectsOtherTypes(t *testing.T) {
+	_, err := FhirStorageModuleFromConfig(ModuleConfig{ModuleId: "fhir_endpoint", ModuleType: "ENDPOINT_FHIR_REST_R4"})
+	if err == nil {
+		t.Fatal("expected an error for a non-persistence module")
+	}
+}
+
+func Test_FhirStorageModuleFromConfigBadOption(t *testing.T) {
+	config := FhirStorageModule{ModuleId: "persistence", FhirVersion: "R4"}.ModuleConfig()
+	config.SetOption(fhirStorageDbMaxConnections, "lots")
+
+	if _, err := FhirStorageModuleFromConfig(config); err == nil {
+		t.Fatal("expected an error for a non-integer option")
+	}
+}
+
+func Test_FhirStorageModuleClearedOptions(t *testing.T) {
+	previous := FhirStorageModule{ModuleId: "persistence", FhirVersion: "R4", DbPassword: "secret"}
+	module := previous
+	module.DbPassword = ""
+
+	cleared := module.ClearedOptions(previous)
+	if len(cleared) != 1 || cleared[0] != fhirStorageDbPassword {
+		t.Fatalf("expected the password to be cleared, got %v", cleared)
+	}
+	if cleared := previous.ClearedOptions(previous); len(cleared) != 0 {
+		t.Fatalf("expected nothing cleared, got %v", cleared)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+// MdmRulesJsonOption holds the match rules of an MDM module.
+const MdmRulesJsonOption = "mdm.rules_json"
+
+func mdmResourceTypeParameters(resourceTypes []string) Parameters {
+	parameters := NewParameters()
+	for _, resourceType := range resourceTypes {
+		parameters.Parameter = append(parameters.Parameter, ParametersParameter{Name: "resourceType", ValueString: resourceType})
+	}
+	return parameters
+}
+
+// MdmClear starts an $mdm-clear batch job removing the golden resources and links of
+// the given resource types, and returns the job instance ID.
+func (fhir *FhirClient) MdmClear(resourceTypes []string) (string, error) {
+	return fhir.StartJob("$mdm-clear", mdmResourceTypeParameters(resourceTypes))
+}
+
+// MdmSubmit submits every resource of the given types for matching. Recent versions
+// run the submission as a batch job and return its instance ID, older versions
+// submit synchronously and return no job ID.
+func (fhir *FhirClient) MdmSubmit(resourceTypes []string) (string, error) {
+	return fhir.StartJob("$mdm-submit", mdmResourceTypeParameters(resourceTypes))
+}
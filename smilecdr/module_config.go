@@ -0,0 +1,274 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Dependency types and module type prefixes used to wire modules together.
+const (
+	DependencyTypePersistence = "PERSISTENCE"
+	DependencyTypeSecurityIn  = "SECURITY_IN"
+
+	PersistenceModuleTypePrefix  = "PERSISTENCE_"
+	SecurityInModuleTypePrefix   = "SECURITY_IN_"
+	FhirEndpointModuleTypePrefix = "ENDPOINT_FHIR_REST_"
+)
+
+// ModuleCallbackScriptOption holds the JavaScript callback script of inbound security modules.
+const ModuleCallbackScriptOption = "callback_script.text"
+
+type ModuleOption struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type ModuleDependency struct {
+	ModuleId string `json:"moduleId"`
+	Type     string `json:"type"`
+}
+
+type ModuleConfig struct {
+	NodeId       string             `json:"nodeId,omitempty"`
+	ModuleId     string             `json:"moduleId"`
+	ModuleType   string             `json:"moduleType"`
+	Options      []ModuleOption     `json:"options"`
+	Dependencies []ModuleDependency `json:"dependencies,omitempty"`
+	ArchivedAt   string             `json:"archivedAt,omitempty"`
+}
+
+type NodeConfig struct {
+	NodeId  string         `json:"nodeId"`
+	Modules []ModuleConfig `json:"modules"`
+}
+
+// Module returns the active (non-archived) module with the given ID.
+func (n *NodeConfig) Module(moduleId string) (ModuleConfig, bool) {
+	for _, module := range n.Modules {
+		if module.ModuleId == moduleId && module.ArchivedAt == "" {
+			return module, true
+		}
+	}
+	return ModuleConfig{}, false
+}
+
+// OptionsMap returns the module options keyed by option name.
+func (m *ModuleConfig) OptionsMap() map[string]string {
+	options := make(map[string]string, len(m.Options))
+	for _, option := range m.Options {
+		options[option.Key] = option.Value
+	}
+	return options
+}
+
+// Option returns the value of the named option, or "" when it is not set.
+func (m *ModuleConfig) Option(key string) string {
+	for _, option := range m.Options {
+		if option.Key == key {
+			return option.Value
+		}
+	}
+	return ""
+}
+
+// SetOption sets the named option, replacing any existing value.
+func (m *ModuleConfig) SetOption(key string, value string) {
+	for i, option := range m.Options {
+		if option.Key == key {
+			m.Options[i].Value = value
+			return
+		}
+	}
+	m.Options = append(m.Options, ModuleOption{Key: key, Value: value})
+}
+
+// DeleteOption removes the named option, if it is set.
+func (m *ModuleConfig) DeleteOption(key string) {
+	for i, option := range m.Options {
+		if option.Key == key {
+			m.Options = append(m.Options[:i], m.Options[i+1:]...)
+			return
+		}
+	}
+}
+
+// optionBool parses a boolean option, recording the first parse failure in err.
+// Unset options read as false.
+func optionBool(config ModuleConfig, key string, err *error) bool {
+	value := config.Option(key)
+	if value == "" {
+		return false
+	}
+	b, parseErr := strconv.ParseBool(value)
+	if parseErr != nil && *err == nil {
+		*err = fmt.Errorf("option %s of module %s is not a boolean: %q", key, config.ModuleId, value)
+	}
+	return b
+}
+
+// optionInt parses an integer option, recording the first parse failure in err.
+// Unset options read as 0.
+func optionInt(config ModuleConfig, key string, err *error) int {
+	value := config.Option(key)
+	if value == "" {
+		return 0
+	}
+	i, parseErr := strconv.Atoi(value)
+	if parseErr != nil && *err == nil {
+		*err = fmt.Errorf("option %s of module %s is not an integer: %q", key, config.ModuleId, value)
+	}
+	return i
+}
+
+func (smilecdr *Client) GetModuleConfigs(nodeId string) (NodeConfig, error) {
+	var node NodeConfig
+	var endpoint = fmt.Sprintf("/module-config/%s", nodeId)
+	jsonBody, getErr := smilecdr.Get(endpoint)
+	if getErr != nil {
+		fmt.Println("error during Get in GetModuleConfigs:", getErr)
+		return node, getErr
+	}
+
+	err := json.Unmarshal(jsonBody, &node)
+	if err != nil {
+		fmt.Println("error parsing Get response JSON:", err)
+	}
+	if node.NodeId == "" {
+		node.NodeId = nodeId
+	}
+	for i := range node.Modules {
+		node.Modules[i].NodeId = node.NodeId
+	}
+
+	return node, err
+}
+
+func (smilecdr *Client) GetModuleConfig(nodeId string, moduleId string) (ModuleConfig, error) {
+	var config ModuleConfig
+	var endpoint = fmt.Sprintf("/module-config/%s/%s", nodeId, moduleId)
+	jsonBody, getErr := smilecdr.Get(endpoint)
+	if getErr != nil {
+		fmt.Println("error during Get in GetModuleConfig:", getErr)
+		return config, getErr
+	}
+
+	err := json.Unmarshal(jsonBody, &config)
+	if err != nil {
+		fmt.Println("error parsing Get response JSON:", err)
+	}
+	if config.NodeId == "" {
+		config.NodeId = nodeId
+	}
+
+	return config, err
+}
+
+func (smilecdr *Client) PostModuleConfig(config ModuleConfig) (ModuleConfig, error) {
+	var newConfig ModuleConfig
+	var endpoint = fmt.Sprintf("/module-config/%s/create", config.NodeId)
+	jsonBody, _ := json.Marshal(config)
+
+	jsonBody, postErr := smilecdr.Post(endpoint, jsonBody)
+	if postErr != nil {
+		fmt.Println("error during Post in PostModuleConfig:", postErr)
+		return newConfig, postErr
+	}
+
+	err := json.Unmarshal(jsonBody, &newConfig)
+	if err != nil {
+		fmt.Println("error parsing Post response JSON:", err)
+	}
+
+	return newConfig, err
+}
+
+func (smilecdr *Client) PutModuleConfig(config ModuleConfig) (ModuleConfig, error) {
+	var newConfig ModuleConfig
+	var endpoint = fmt.Sprintf("/module-config/%s/%s/set", config.NodeId, config.ModuleId)
+	jsonBody, _ := json.Marshal(config)
+
+	jsonBody, putErr := smilecdr.Put(endpoint, jsonBody)
+	if putErr != nil {
+		fmt.Println("error during Put in PutModuleConfig:", putErr)
+		return newConfig, putErr
+	}
+
+	err := json.Unmarshal(jsonBody, &newConfig)
+	if err != nil {
+		fmt.Println("error parsing Put response JSON:", err)
+	}
+
+	return newConfig, err
+}
+
+func (smilecdr *Client) ArchiveModuleConfig(nodeId string, moduleId string) error {
+	var endpoint = fmt.Sprintf("/module-config/%s/%s/archive", nodeId, moduleId)
+	_, err := smilecdr.Post(endpoint, []byte("{}"))
+
+	return err
+}
+
+func (smilecdr *Client) ReinstateModuleConfig(nodeId string, moduleId string) error {
+	var endpoint = fmt.Sprintf("/module-config/%s/%s/reinstate", nodeId, moduleId)
+	_, err := smilecdr.Post(endpoint, []byte("{}"))
+
+	return err
+}
+
+// Module states reported by the module status endpoint.
+const (
+	ModuleStatusStarted  = "STARTED"
+	ModuleStatusStarting = "STARTING"
+	ModuleStatusStopped  = "STOPPED"
+	ModuleStatusStopping = "STOPPING"
+	ModuleStatusFailed   = "FAILED"
+)
+
+type ModuleStatus struct {
+	ModuleId       string `json:"moduleId,omitempty"`
+	Status         string `json:"status"`
+	StartTime      string `json:"startTime,omitempty"`
+	FailureMessage string `json:"failureMessage,omitempty"`
+}
+
+func (smilecdr *Client) GetModuleStatus(nodeId string, moduleId string) (ModuleStatus, error) {
+	var status ModuleStatus
+	var endpoint = fmt.Sprintf("/module-config/%s/%s/status", nodeId, moduleId)
+	jsonBody, getErr := smilecdr.Get(endpoint)
+	if getErr != nil {
+		fmt.Println("error during Get in GetModuleStatus:", getErr)
+		return status, getErr
+	}
+
+	err := json.Unmarshal(jsonBody, &status)
+	if err != nil {
+		fmt.Println("error parsing Get response JSON:", err)
+	}
+
+	return status, err
+}
+
+func (smilecdr *Client) StartModule(nodeId string, moduleId string) error {
+	var endpoint = fmt.Sprintf("/module-config/%s/%s/start", nodeId, moduleId)
+	_, err := smilecdr.Post(endpoint, []byte("{}"))
+
+	return err
+}
+
+func (smilecdr *Client) StopModule(nodeId string, moduleId string) error {
+	var endpoint = fmt.Sprintf("/module-config/%s/%s/stop", nodeId, moduleId)
+	_, err := smilecdr.Post(endpoint, []byte("{}"))
+
+	return err
+}
+
+func (smilecdr *Client) RestartModule(nodeId string, moduleId string) error {
+	var endpoint = fmt.Sprintf("/module-config/%s/%s/restart", nodeId, moduleId)
+	_, err := smilecdr.Post(endpoint, []byte("{}"))
+
+	return err
+}
@@ -46,6 +46,7 @@ type OpenIdClient struct {
 	Permissions                 []UserPermission `json:"permissions,omitempty"`
 	AttestationAccepted         bool             `json:"rememberedScopes,omitempty"`
 	PublicJwksUri               string           `json:"publicJwksUri,omitempty"`
+	PublicJwks                  string           `json:"publicJwks,omitempty"`
 	ArchivedAt                  string           `json:"archivedAt,omitempty"`
 	CreatedByAppSphere          bool             `json:"createdByAppSphere,omitempty"`
 }
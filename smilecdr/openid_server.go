@@ -0,0 +1,89 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type OpenIdServer struct {
+	Pid                 int    `json:"pid,omitempty"`
+	NodeId              string `json:"nodeId,omitempty"`
+	ModuleId            string `json:"moduleId,omitempty"`
+	Name                string `json:"name,omitempty"`
+	Issuer              string `json:"issuer,omitempty"`
+	ValidationJwkText   string `json:"validationJwkText,omitempty"`
+	FedJwkSetUrl        string `json:"fedJwkSetUrl,omitempty"`
+	FedClientId         string `json:"fedClientId,omitempty"`
+	FedClientSecret     string `json:"fedClientSecret,omitempty"`
+	FedAuthorizationUrl string `json:"fedAuthorizationUrl,omitempty"`
+	FedTokenUrl         string `json:"fedTokenUrl,omitempty"`
+	FedUserInfoUrl      string `json:"fedUserInfoUrl,omitempty"`
+	FedRequestScopes    string `json:"fedRequestScopes,omitempty"`
+	AuthScriptText      string `json:"authScriptText,omitempty"`
+	ArchivedAt          string `json:"archivedAt,omitempty"`
+}
+
+func (smilecdr *Client) GetOpenIdServer(nodeId string, moduleId string, pid int) (OpenIdServer, error) {
+	var server OpenIdServer
+	var endpoint = fmt.Sprintf("/openid-connect-servers/%s/%s/%d", nodeId, moduleId, pid)
+	jsonBody, getErr := smilecdr.Get(endpoint)
+	if getErr != nil {
+		fmt.Println("error during Get in GetOpenIdServer:", getErr)
+		return server, getErr
+	}
+
+	err := json.Unmarshal(jsonBody, &server)
+	if err != nil {
+		fmt.Println("error parsing Get response JSON:", err)
+	}
+
+	return server, err
+}
+
+func (smilecdr *Client) PostOpenIdServer(server OpenIdServer) (OpenIdServer, error) {
+	var newServer OpenIdServer
+	var endpoint = fmt.Sprintf("/openid-connect-servers/%s/%s", server.NodeId, server.ModuleId)
+	jsonBody, _ := json.Marshal(server)
+
+	jsonBody, postErr := smilecdr.Post(endpoint, jsonBody)
+	if postErr != nil {
+		fmt.Println("error during Post in PostOpenIdServer:", postErr)
+		return newServer, postErr
+	}
+
+	err := json.Unmarshal(jsonBody, &newServer)
+	if err != nil {
+		fmt.Println("error parsing Post response JSON:", err)
+	}
+
+	return newServer, err
+}
+
+func (smilecdr *Client) PutOpenIdServer(server OpenIdServer) (OpenIdServer, error) {
+	var newServer OpenIdServer
+	var endpoint = fmt.Sprintf("/openid-connect-servers/%s/%s/%d", server.NodeId, server.ModuleId, server.Pid)
+	jsonBody, _ := json.Marshal(server)
+
+	jsonBody, putErr := smilecdr.Put(endpoint, jsonBody)
+	if putErr != nil {
+		fmt.Println("error during Put in PutOpenIdServer:", putErr)
+		return newServer, putErr
+	}
+
+	err := json.Unmarshal(jsonBody, &newServer)
+	if err != nil {
+		fmt.Println("error parsing Put response JSON:", err)
+	}
+
+	return newServer, err
+}
+
+func (smilecdr *Client) DeleteOpenIdServer(nodeId string, moduleId string, pid int) error {
+	var endpoint = fmt.Sprintf("/openid-connect-servers/%s/%s/%d", nodeId, moduleId, pid)
+	_, err := smilecdr.Delete(endpoint)
+
+	return err
+}
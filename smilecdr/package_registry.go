@@ -0,0 +1,89 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Package install modes. STORE_ONLY adds the package to the registry, STORE_AND_INSTALL
+// also installs its conformance resources into the repository.
+const (
+	PackageInstallModeStoreOnly       = "STORE_ONLY"
+	PackageInstallModeStoreAndInstall = "STORE_AND_INSTALL"
+)
+
+// PackageInstallationSpec describes an NPM package to install. The package is fetched
+// from PackageUrl, or from the configured registry by name and version, unless its
+// contents are uploaded in PackageContents.
+type PackageInstallationSpec struct {
+	Name                 string   `json:"name"`
+	Version              string   `json:"version"`
+	PackageUrl           string   `json:"packageUrl,omitempty"`
+	PackageContents      []byte   `json:"packageContents,omitempty"`
+	InstallMode          string   `json:"installMode"`
+	InstallResourceTypes []string `json:"installResourceTypes,omitempty"`
+	FetchDependencies    bool     `json:"fetchDependencies"`
+}
+
+type PackageInstallOutcome struct {
+	MessageList    []string       `json:"messageList,omitempty"`
+	FilesInstalled map[string]int `json:"filesInstalled,omitempty"`
+}
+
+type NpmPackage struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+	FhirVersion string `json:"fhirVersion,omitempty"`
+}
+
+func packageEndpoint(nodeId string, moduleId string, name string, version string) string {
+	return fmt.Sprintf("/package-registry/%s/%s/%s/%s", nodeId, moduleId, url.PathEscape(name), url.PathEscape(version))
+}
+
+func (smilecdr *Client) GetPackage(nodeId string, moduleId string, name string, version string) (NpmPackage, error) {
+	var npmPackage NpmPackage
+	jsonBody, getErr := smilecdr.Get(packageEndpoint(nodeId, moduleId, name, version))
+	if getErr != nil {
+		fmt.Println("error during Get in GetPackage:", getErr)
+		return npmPackage, getErr
+	}
+
+	err := json.Unmarshal(jsonBody, &npmPackage)
+	if err != nil {
+		fmt.Println("error parsing Get response JSON:", err)
+	}
+
+	return npmPackage, err
+}
+
+func (smilecdr *Client) InstallPackage(nodeId string, moduleId string, spec PackageInstallationSpec) (PackageInstallOutcome, error) {
+	var outcome PackageInstallOutcome
+	var endpoint = fmt.Sprintf("/package-registry/%s/%s/install", nodeId, moduleId)
+	jsonBody, _ := json.Marshal(spec)
+
+	jsonBody, postErr := smilecdr.Post(endpoint, jsonBody)
+	if postErr != nil {
+		fmt.Println("error during Post in InstallPackage:", postErr)
+		return outcome, postErr
+	}
+
+	err := json.Unmarshal(jsonBody, &outcome)
+	if err != nil {
+		fmt.Println("error parsing Post response JSON:", err)
+	}
+
+	return outcome, err
+}
+
+func (smilecdr *Client) UninstallPackage(nodeId string, moduleId string, name string, version string) error {
+	_, err := smilecdr.Delete(packageEndpoint(nodeId, moduleId, name, version))
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"fmt"
+)
+
+// DefaultPartitionName is the partition the partition management operations are invoked on.
+const DefaultPartitionName = "DEFAULT"
+
+type Partition struct {
+	Id          int
+	Name        string
+	Description string
+}
+
+func (partition Partition) parameters() Parameters {
+	id := partition.Id
+	parameters := NewParameters(
+		ParametersParameter{Name: "id", ValueInteger: &id},
+		ParametersParameter{Name: "name", ValueCode: partition.Name},
+	)
+	if partition.Description != "" {
+		parameters.Parameter = append(parameters.Parameter, ParametersParameter{Name: "description", ValueString: partition.Description})
+	}
+	return parameters
+}
+
+func (partition Partition) idParameters() Parameters {
+	id := partition.Id
+	return NewParameters(ParametersParameter{Name: "id", ValueInteger: &id})
+}
+
+func partitionFromParameters(parameters Parameters) Partition {
+	id, _ := parameters.Int("id")
+	return Partition{
+		Id:          id,
+		Name:        parameters.String("name"),
+		Description: parameters.String("description"),
+	}
+}
+
+func (fhir *FhirClient) partitionOperation(operation string, parameters Parameters) (Partition, error) {
+	response, err := fhir.ForPartition(DefaultPartitionName).InvokeOperation(operation, parameters)
+	if err != nil {
+		fmt.Printf("error during Operation in %s: %s\n", operation, err)
+		return Partition{}, err
+	}
+	return partitionFromParameters(response), nil
+}
+
+func (fhir *FhirClient) GetPartition(id int) (Partition, error) {
+	return fhir.partitionOperation("$partition-management-read-partition", Partition{Id: id}.idParameters())
+}
+
+func (fhir *FhirClient) PostPartition(partition Partition) (Partition, error) {
+	return fhir.partitionOperation("$partition-management-create-partition", partition.parameters())
+}
+
+func (fhir *FhirClient) PutPartition(partition Partition) (Partition, error) {
+	return fhir.partitionOperation("$partition-management-update-partition", partition.parameters())
+}
+
+func (fhir *FhirClient) DeletePartition(id int) error {
+	_, err := fhir.ForPartition(DefaultPartitionName).InvokeOperation("$partition-management-delete-partition", Partition{Id: id}.idParameters())
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// CountResources counts the stored resources of every type with one live,
+// system-level _summary=count search, so recent writes are included.
+func (fhir *FhirClient) CountResources() (int, error) {
+	count, err := fhir.Count("", nil)
+	if err != nil {
+		fmt.Println("error during Search in CountResources:", err)
+	}
+	return count, err
+}
@@ -0,0 +1,109 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type ProcessStatus struct {
+	ProcessId   string         `json:"processId"`
+	NodeVersion string         `json:"nodeVersion"`
+	BuildNumber string         `json:"buildNumber,omitempty"`
+	StartTime   string         `json:"startTime,omitempty"`
+	Modules     []ModuleStatus `json:"modules,omitempty"`
+}
+
+type NodeStatus struct {
+	NodeId    string                   `json:"nodeId"`
+	Processes map[string]ProcessStatus `json:"processes"`
+}
+
+type nodeStatuses struct {
+	NodeStatuses map[string]NodeStatus `json:"nodeStatuses"`
+}
+
+// SortedProcesses returns the node's processes ordered by process ID.
+func (n *NodeStatus) SortedProcesses() []ProcessStatus {
+	processIds := make([]string, 0, len(n.Processes))
+	for processId := range n.Processes {
+		processIds = append(processIds, processId)
+	}
+	sort.Strings(processIds)
+	processes := make([]ProcessStatus, len(processIds))
+	for i, processId := range processIds {
+		processes[i] = n.Processes[processId]
+		if processes[i].ProcessId == "" {
+			processes[i].ProcessId = processId
+		}
+	}
+	return processes
+}
+
+// Version returns the software version the node runs. All processes of a node run
+// the same version, so the first process answers for the node.
+func (n *NodeStatus) Version() string {
+	for _, process := range n.SortedProcesses() {
+		if process.NodeVersion != "" {
+			return process.NodeVersion
+		}
+	}
+	return ""
+}
+
+// Build returns the build number of the node's software, see Version.
+func (n *NodeStatus) Build() string {
+	for _, process := range n.SortedProcesses() {
+		if process.BuildNumber != "" {
+			return process.BuildNumber
+		}
+	}
+	return ""
+}
+
+// StartedAt returns when the node's longest running process started, or the zero
+// time when no process reports a start time.
+func (n *NodeStatus) StartedAt() (time.Time, error) {
+	var started time.Time
+	for _, process := range n.SortedProcesses() {
+		if process.StartTime == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, process.StartTime)
+		if err != nil {
+			return started, fmt.Errorf("process %s has an invalid start time: %q", process.ProcessId, process.StartTime)
+		}
+		if started.IsZero() || t.Before(started) {
+			started = t
+		}
+	}
+	return started, nil
+}
+
+// GetNodeStatuses returns the status of every node in the cluster, keyed by node ID.
+func (smilecdr *Client) GetNodeStatuses() (map[string]NodeStatus, error) {
+	var statuses nodeStatuses
+	var endpoint = "/runtime-status/node-statuses/complete"
+	jsonBody, getErr := smilecdr.Get(endpoint)
+	if getErr != nil {
+		fmt.Println("error during Get in GetNodeStatuses:", getErr)
+		return nil, getErr
+	}
+
+	err := json.Unmarshal(jsonBody, &statuses)
+	if err != nil {
+		fmt.Println("error parsing Get response JSON:", err)
+	}
+	for nodeId, status := range statuses.NodeStatuses {
+		if status.NodeId == "" {
+			status.NodeId = nodeId
+			statuses.NodeStatuses[nodeId] = status
+		}
+	}
+
+	return statuses.NodeStatuses, err
+}
@@ -0,0 +1,37 @@
+package smilecdr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_GetNodeStatuses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/runtime-status/node-statuses/complete" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"nodeStatuses":{"Master":{"processes":{
+			"Master-2":{"nodeVersion":"2023.05.R02","buildNumber":"2231","startTime":"2023-06-01T10:00:00Z"},
+			"Master-1":{"nodeVersion":"2023.05.R02","buildNumber":"2231","startTime":"2023-06-01T09:00:00Z"}
+		}}}}`))
+	}))
+	defer server.Close()
+
+	statuses, err := NewClient(server.URL, "admin", "password").GetNodeStatuses()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	node, ok := statuses["Master"]
+	if !ok || node.NodeId != "Master" {
+		t.Fatalf("expected the Master node, got %+v", statuses)
+	}
+	if node.Version() != "2023.05.R02" || node.Build() != "2231" {
+		t.Fatalf("unexpected version %s build %s", node.Version(), node.Build())
+	}
+	started, err := node.StartedAt()
+	if err != nil || !started.Equal(time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected start time %s: %v", started, err)
+	}
+}
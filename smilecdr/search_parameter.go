@@ -0,0 +1,125 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const SearchParameterResourceType = "SearchParameter"
+
+// SearchParameterTypes are the values of SearchParameter.type.
+var SearchParameterTypes = []string{"number", "date", "string", "token", "reference", "composite", "quantity", "uri", "special"}
+
+// PublicationStatuses are the values of SearchParameter.status.
+var PublicationStatuses = []string{"draft", "active", "retired", "unknown"}
+
+type SearchParameterComponent struct {
+	Definition string `json:"definition"`
+	Expression string `json:"expression"`
+}
+
+type SearchParameter struct {
+	ResourceType string                     `json:"resourceType"`
+	Id           string                     `json:"id,omitempty"`
+	Meta         *Meta                      `json:"meta,omitempty"`
+	Url          string                     `json:"url"`
+	Name         string                     `json:"name"`
+	Status       string                     `json:"status"`
+	Description  string                     `json:"description,omitempty"`
+	Code         string                     `json:"code"`
+	Base         []string                   `json:"base"`
+	Type         string                     `json:"type"`
+	Expression   string                     `json:"expression,omitempty"`
+	Target       []string                   `json:"target,omitempty"`
+	Component    []SearchParameterComponent `json:"component,omitempty"`
+}
+
+func (fhir *FhirClient) GetSearchParameter(id string) (SearchParameter, error) {
+	var searchParameter SearchParameter
+	jsonBody, getErr := fhir.Read(SearchParameterResourceType, id)
+	if getErr != nil {
+		fmt.Println("error during Read in GetSearchParameter:", getErr)
+		return searchParameter, getErr
+	}
+
+	err := json.Unmarshal(jsonBody, &searchParameter)
+	if err != nil {
+		fmt.Println("error parsing Read response JSON:", err)
+	}
+
+	return searchParameter, err
+}
+
+// PostSearchParameter creates the search parameter, with a PUT when it has a client assigned ID.
+func (fhir *FhirClient) PostSearchParameter(searchParameter SearchParameter) (SearchParameter, error) {
+	searchParameter.ResourceType = SearchParameterResourceType
+	jsonBody, err := json.Marshal(searchParameter)
+	if err != nil {
+		return searchParameter, err
+	}
+
+	if searchParameter.Id == "" {
+		jsonBody, err = fhir.Create(SearchParameterResourceType, jsonBody)
+	} else {
+		jsonBody, err = fhir.Update(SearchParameterResourceType, searchParameter.Id, jsonBody, "")
+	}
+	if err != nil {
+		fmt.Println("error during Create in PostSearchParameter:", err)
+		return searchParameter, err
+	}
+
+	var created SearchParameter
+	err = json.Unmarshal(jsonBody, &created)
+	if err != nil {
+		fmt.Println("error parsing Create response JSON:", err)
+	}
+
+	return created, err
+}
+
+func (fhir *FhirClient) PutSearchParameter(searchParameter SearchParameter) (SearchParameter, error) {
+	searchParameter.ResourceType = SearchParameterResourceType
+	jsonBody, err := json.Marshal(searchParameter)
+	if err != nil {
+		return searchParameter, err
+	}
+
+	jsonBody, err = fhir.Update(SearchParameterResourceType, searchParameter.Id, jsonBody, "")
+	if err != nil {
+		fmt.Println("error during Update in PutSearchParameter:", err)
+		return searchParameter, err
+	}
+
+	var updated SearchParameter
+	err = json.Unmarshal(jsonBody, &updated)
+	if err != nil {
+		fmt.Println("error parsing Update response JSON:", err)
+	}
+
+	return updated, err
+}
+
+func (fhir *FhirClient) DeleteSearchParameter(id string) error {
+	return fhir.Delete(SearchParameterResourceType, id)
+}
+
+// Reindex starts a $reindex batch job for the given resource types, or for every
+// resource when none are given, and returns the job instance ID.
+func (fhir *FhirClient) Reindex(resourceTypes []string) (string, error) {
+	parameters := NewParameters()
+	for _, resourceType := range resourceTypes {
+		parameters.Parameter = append(parameters.Parameter, ParametersParameter{Name: "url", ValueString: resourceType + "?"})
+	}
+
+	jobId, err := fhir.StartJob("$reindex", parameters)
+	if err != nil {
+		return "", err
+	}
+	if jobId == "" {
+		return "", fmt.Errorf("smilecdr: $reindex did not return a job ID")
+	}
+	return jobId, nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Option keys used by the SMART Outbound Security module.
+const (
+	smartAuthIssuerUrl                   = "issuer.url"
+	smartAuthPort                        = "port"
+	smartAuthAccessTokenValiditySeconds  = "token.access_token_validity_seconds"
+	smartAuthRefreshTokenValiditySeconds = "token.refresh_token_validity_seconds"
+	smartAuthIdTokenValiditySeconds      = "token.id_token_validity_seconds"
+	smartAuthSigningKeystoreId           = "openid.signing.keystore_id"
+	smartAuthApprovalPageEnabled         = "approval_page.enabled"
+	smartAuthApprovalPageTemplate        = "approval_page.custom_template"
+	smartAuthRememberApprovedScopes      = "approval_page.remember_approved_scopes"
+	smartAuthConsentPageEnabled          = "consent_page.enabled"
+	smartAuthConsentPageTemplate         = "consent_page.custom_template"
+	smartAuthFederateEnabled             = "federate.enabled"
+	smartAuthFederateSkipLocalLogin      = "federate.skip_local_login"
+)
+
+const SmartAuthModuleType = "SECURITY_OUT_SMART"
+
+// SmartAuthSigningJwksOption holds the JWK set the SMART module signs tokens with. It
+// is owned by the signing keystore resource, SmartAuthModule never writes it.
+const SmartAuthSigningJwksOption = "openid.signing.jwks_text"
+
+type SmartAuthModule struct {
+	NodeId                      string
+	ModuleId                    string
+	IssuerUrl                   string
+	Port                        int
+	AccessTokenValiditySeconds  int
+	RefreshTokenValiditySeconds int
+	IdTokenValiditySeconds      int
+	SigningKeystoreId           string
+	ApprovalPageEnabled         bool
+	ApprovalPageTemplate        string
+	RememberApprovedScopes      bool
+	ConsentPageEnabled          bool
+	ConsentPageTemplate         string
+	FederatedLoginEnabled       bool
+	FederatedLoginSkipLocal     bool
+	SecurityModuleId            string
+}
+
+// ModuleConfig maps the typed module onto the generic module-config representation.
+func (m SmartAuthModule) ModuleConfig() ModuleConfig {
+	config := ModuleConfig{
+		NodeId:       m.NodeId,
+		ModuleId:     m.ModuleId,
+		ModuleType:   SmartAuthModuleType,
+		Options:      make([]ModuleOption, 0),
+		Dependencies: make([]ModuleDependency, 0),
+	}
+
+	config.SetOption(smartAuthIssuerUrl, m.IssuerUrl)
+	config.SetOption(smartAuthPort, strconv.Itoa(m.Port))
+	config.SetOption(smartAuthAccessTokenValiditySeconds, strconv.Itoa(m.AccessTokenValiditySeconds))
+	config.SetOption(smartAuthRefreshTokenValiditySeconds, strconv.Itoa(m.RefreshTokenValiditySeconds))
+	config.SetOption(smartAuthIdTokenValiditySeconds, strconv.Itoa(m.IdTokenValiditySeconds))
+	config.SetOption(smartAuthApprovalPageEnabled, strconv.FormatBool(m.ApprovalPageEnabled))
+	config.SetOption(smartAuthRememberApprovedScopes, strconv.FormatBool(m.RememberApprovedScopes))
+	config.SetOption(smartAuthConsentPageEnabled, strconv.FormatBool(m.ConsentPageEnabled))
+	for key, value := range m.optionalOptions() {
+		if value != "" {
+			config.SetOption(key, value)
+		}
+	}
+	config.SetOption(smartAuthFederateEnabled, strconv.FormatBool(m.FederatedLoginEnabled))
+	config.SetOption(smartAuthFederateSkipLocalLogin, strconv.FormatBool(m.FederatedLoginSkipLocal))
+
+	if m.SecurityModuleId != "" {
+		config.Dependencies = append(config.Dependencies, ModuleDependency{ModuleId: m.SecurityModuleId, Type: DependencyTypeSecurityIn})
+	}
+
+	return config
+}
+
+// optionalOptions maps the options that are only written when set, so an unset
+// template or keystore never overwrites the server's value with an empty string.
+func (m SmartAuthModule) optionalOptions() map[string]string {
+	return map[string]string{
+		smartAuthSigningKeystoreId:    m.SigningKeystoreId,
+		smartAuthApprovalPageTemplate: m.ApprovalPageTemplate,
+		smartAuthConsentPageTemplate:  m.ConsentPageTemplate,
+	}
+}
+
+// ClearedOptions returns the keys of the optional settings that previous set and m
+// leaves out. ModuleConfig does not write them, so they must be removed instead.
+func (m SmartAuthModule) ClearedOptions(previous SmartAuthModule) []string {
+	var keys []string
+	current := m.optionalOptions()
+	for key, value := range previous.optionalOptions() {
+		if value != "" && current[key] == "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SmartAuthModuleFromConfig reads the typed module back out of a module-config response.
+func SmartAuthModuleFromConfig(config ModuleConfig) (SmartAuthModule, error) {
+	var err error
+	module := SmartAuthModule{
+		NodeId:               config.NodeId,
+		ModuleId:             config.ModuleId,
+		IssuerUrl:            config.Option(smartAuthIssuerUrl),
+		SigningKeystoreId:    config.Option(smartAuthSigningKeystoreId),
+		ApprovalPageTemplate: config.Option(smartAuthApprovalPageTemplate),
+		ConsentPageTemplate:  config.Option(smartAuthConsentPageTemplate),
+	}
+
+	if config.ModuleType != SmartAuthModuleType {
+		return module, fmt.Errorf("module %s has type %s, expected a %s module", config.ModuleId, config.ModuleType, SmartAuthModuleType)
+	}
+
+	module.Port = optionInt(config, smartAuthPort, &err)
+	module.AccessTokenValiditySeconds = optionInt(config, smartAuthAccessTokenValiditySeconds, &err)
+	module.RefreshTokenValiditySeconds = optionInt(config, smartAuthRefreshTokenValiditySeconds, &err)
+	module.IdTokenValiditySeconds = optionInt(config, smartAuthIdTokenValiditySeconds, &err)
+	module.ApprovalPageEnabled = optionBool(config, smartAuthApprovalPageEnabled, &err)
+	module.RememberApprovedScopes = optionBool(config, smartAuthRememberApprovedScopes, &err)
+	module.ConsentPageEnabled = optionBool(config, smartAuthConsentPageEnabled, &err)
+	module.FederatedLoginEnabled = optionBool(config, smartAuthFederateEnabled, &err)
+	module.FederatedLoginSkipLocal = optionBool(config, smartAuthFederateSkipLocalLogin, &err)
+
+	for _, dependency := range config.Dependencies {
+		if dependency.Type == DependencyTypeSecurityIn {
+			module.SecurityModuleId = dependency.ModuleId
+		}
+	}
+
+	return module, err
+}
@@ -0,0 +1,55 @@
+package smilecdr
+
+import (
+	"testing"
+)
+
+func Test_SmartAuthModuleRoundTrip(t *testing.T) {
+	module := SmartAuthModule{
+		NodeId:                      "Master",
+		ModuleId:                    "smart_auth",
+		IssuerUrl:                   "https://auth.example.org",
+		Port:                        9200,
+		AccessTokenValiditySeconds:  300,
+		RefreshTokenValiditySeconds: 86400,
+		IdTokenValiditySeconds:      300,
+		SigningKeystoreId:           "default-keystore",
+		ApprovalPageEnabled:         true,
+		ApprovalPageTemplate:        "<html>approve</html>",
+		RememberApprovedScopes:      true,
+		ConsentPageEnabled:          true,
+		ConsentPageTemplate:         "<html>consent</html>",
+		SecurityModuleId:            "local_security",
+	}
+
+	config := module.ModuleConfig()
+	if config.Option(smartAuthConsentPageEnabled) != "true" || config.Option(smartAuthConsentPageTemplate) != "<html>consent</html>" {
+		t.Fatalf("consent page options not set: %+v", config.Options)
+	}
+
+	parsed, err := SmartAuthModuleFromConfig(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if parsed != module {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", parsed, module)
+	}
+}
+
+func Test_SmartAuthModuleLeavesUnsetOptions(t *testing.T) {
+	module := SmartAuthModule{ModuleId: "smart_auth", Port: 9200}
+
+	config := module.ModuleConfig()
+	for _, key := range []string{SmartAuthSigningJwksOption, smartAuthSigningKeystoreId, smartAuthApprovalPageTemplate, smartAuthConsentPageTemplate} {
+		if _, ok := config.OptionsMap()[key]; ok {
+			t.Errorf("expected %s to be left out", key)
+		}
+	}
+
+	previous := module
+	previous.ApprovalPageTemplate = "<html>approve</html>"
+	cleared := module.ClearedOptions(previous)
+	if len(cleared) != 1 || cleared[0] != smartAuthApprovalPageTemplate {
+		t.Fatalf("expected the approval template to be cleared, got %v", cleared)
+	}
+}
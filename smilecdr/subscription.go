@@ -0,0 +1,164 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const SubscriptionResourceType = "Subscription"
+
+// Subscription statuses. New subscriptions are requested and become active once
+// Smile CDR has registered them; error and off subscriptions do not deliver.
+const (
+	SubscriptionStatusRequested = "requested"
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusError     = "error"
+	SubscriptionStatusOff       = "off"
+)
+
+// SubscriptionChannelTypes are the supported delivery channels.
+var SubscriptionChannelTypes = []string{"rest-hook", "websocket", "message"}
+
+// SubscriptionContents are the payload contents of topic-based subscriptions.
+var SubscriptionContents = []string{"empty", "id-only", "full-resource"}
+
+// Canonical URLs used by R4 subscriptions following the subscriptions backport IG.
+const (
+	BackportSubscriptionProfile     = "http://hl7.org/fhir/uv/subscriptions-backport/StructureDefinition/backport-subscription"
+	BackportFilterCriteriaExtension = "http://hl7.org/fhir/uv/subscriptions-backport/StructureDefinition/backport-filter-criteria"
+	BackportPayloadContentExtension = "http://hl7.org/fhir/uv/subscriptions-backport/StructureDefinition/backport-payload-content"
+	SubscriptionChannelTypeSystem   = "http://terminology.hl7.org/CodeSystem/subscription-channel-type"
+)
+
+type Extension struct {
+	Url         string `json:"url"`
+	ValueString string `json:"valueString,omitempty"`
+	ValueCode   string `json:"valueCode,omitempty"`
+}
+
+// Element holds the extensions of a primitive, serialised as "_name" in FHIR JSON.
+type Element struct {
+	Extension []Extension `json:"extension,omitempty"`
+}
+
+type Coding struct {
+	System string `json:"system,omitempty"`
+	Code   string `json:"code"`
+}
+
+// SubscriptionChannel is the R4 channel of a Subscription.
+type SubscriptionChannel struct {
+	Type           string   `json:"type"`
+	Endpoint       string   `json:"endpoint,omitempty"`
+	Payload        string   `json:"payload,omitempty"`
+	PayloadElement *Element `json:"_payload,omitempty"`
+	Header         []string `json:"header,omitempty"`
+}
+
+// SubscriptionFilterBy is an R5 topic-based subscription filter.
+type SubscriptionFilterBy struct {
+	ResourceType    string `json:"resourceType,omitempty"`
+	FilterParameter string `json:"filterParameter"`
+	Comparator      string `json:"comparator,omitempty"`
+	Modifier        string `json:"modifier,omitempty"`
+	Value           string `json:"value"`
+}
+
+// Subscription covers both the R4 resource, including the backport profile for
+// topic-based subscriptions, and the R5 resource. Fields only one version has
+// are left empty for the other.
+type Subscription struct {
+	ResourceType string `json:"resourceType"`
+	Id           string `json:"id,omitempty"`
+	Meta         *Meta  `json:"meta,omitempty"`
+	Status       string `json:"status"`
+	Reason       string `json:"reason,omitempty"`
+	End          string `json:"end,omitempty"`
+
+	// R4
+	Criteria        string               `json:"criteria,omitempty"`
+	CriteriaElement *Element             `json:"_criteria,omitempty"`
+	Error           string               `json:"error,omitempty"`
+	Channel         *SubscriptionChannel `json:"channel,omitempty"`
+
+	// R5
+	Topic       string                 `json:"topic,omitempty"`
+	FilterBy    []SubscriptionFilterBy `json:"filterBy,omitempty"`
+	ChannelType *Coding                `json:"channelType,omitempty"`
+	Endpoint    string                 `json:"endpoint,omitempty"`
+	Header      []string               `json:"header,omitempty"`
+	ContentType string                 `json:"contentType,omitempty"`
+	Content     string                 `json:"content,omitempty"`
+}
+
+func (fhir *FhirClient) GetSubscription(id string) (Subscription, error) {
+	var subscription Subscription
+	jsonBody, getErr := fhir.Read(SubscriptionResourceType, id)
+	if getErr != nil {
+		fmt.Println("error during Read in GetSubscription:", getErr)
+		return subscription, getErr
+	}
+
+	err := json.Unmarshal(jsonBody, &subscription)
+	if err != nil {
+		fmt.Println("error parsing Read response JSON:", err)
+	}
+
+	return subscription, err
+}
+
+// PostSubscription creates the subscription, with a PUT when it has a client assigned ID.
+func (fhir *FhirClient) PostSubscription(subscription Subscription) (Subscription, error) {
+	subscription.ResourceType = SubscriptionResourceType
+	jsonBody, err := json.Marshal(subscription)
+	if err != nil {
+		return subscription, err
+	}
+
+	if subscription.Id == "" {
+		jsonBody, err = fhir.Create(SubscriptionResourceType, jsonBody)
+	} else {
+		jsonBody, err = fhir.Update(SubscriptionResourceType, subscription.Id, jsonBody, "")
+	}
+	if err != nil {
+		fmt.Println("error during Create in PostSubscription:", err)
+		return subscription, err
+	}
+
+	var created Subscription
+	err = json.Unmarshal(jsonBody, &created)
+	if err != nil {
+		fmt.Println("error parsing Create response JSON:", err)
+	}
+
+	return created, err
+}
+
+func (fhir *FhirClient) PutSubscription(subscription Subscription) (Subscription, error) {
+	subscription.ResourceType = SubscriptionResourceType
+	jsonBody, err := json.Marshal(subscription)
+	if err != nil {
+		return subscription, err
+	}
+
+	jsonBody, err = fhir.Update(SubscriptionResourceType, subscription.Id, jsonBody, "")
+	if err != nil {
+		fmt.Println("error during Update in PutSubscription:", err)
+		return subscription, err
+	}
+
+	var updated Subscription
+	err = json.Unmarshal(jsonBody, &updated)
+	if err != nil {
+		fmt.Println("error parsing Update response JSON:", err)
+	}
+
+	return updated, err
+}
+
+func (fhir *FhirClient) DeleteSubscription(id string) error {
+	return fhir.Delete(SubscriptionResourceType, id)
+}
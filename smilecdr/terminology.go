@@ -0,0 +1,62 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// TerminologyUploadOutcome is the result of $upload-external-code-system. Large
+// distributions are loaded by a batch job, in which case JobId is set.
+type TerminologyUploadOutcome struct {
+	JobId        string
+	ConceptCount int
+}
+
+// UploadExternalCodeSystem uploads the files of a code system distribution, such as
+// the SNOMED CT RF2 or LOINC zip files, or custom concepts.csv/hierarchy.csv files.
+func (fhir *FhirClient) UploadExternalCodeSystem(system string, paths []string) (TerminologyUploadOutcome, error) {
+	var outcome TerminologyUploadOutcome
+
+	files := make([]MultipartFile, 0, len(paths))
+	for _, path := range paths {
+		files = append(files, MultipartFile{Field: "file", Filename: path})
+	}
+
+	jsonBody, err := fhir.Upload("CodeSystem/$upload-external-code-system", map[string]string{"system": system}, files)
+	if err != nil {
+		fmt.Println("error during Upload in UploadExternalCodeSystem:", err)
+		return outcome, err
+	}
+
+	var response Parameters
+	err = json.Unmarshal(jsonBody, &response)
+	if err != nil {
+		fmt.Println("error parsing Upload response JSON:", err)
+		return outcome, err
+	}
+
+	outcome.JobId = response.String("jobId")
+	outcome.ConceptCount, _ = response.Int("conceptCount")
+	return outcome, nil
+}
+
+// FindCodeSystem returns the ID of the CodeSystem with the given canonical URL,
+// or an empty string when there is none.
+func (fhir *FhirClient) FindCodeSystem(system string) (string, error) {
+	entries, err := fhir.Search("CodeSystem", url.Values{"url": {system}, "_elements": {"id"}})
+	if err != nil {
+		fmt.Println("error during Search in FindCodeSystem:", err)
+		return "", err
+	}
+	for _, entry := range entries {
+		meta, err := ParseResourceMeta(entry.Resource)
+		if err == nil && meta.Id != "" {
+			return meta.Id, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Version is a Smile CDR release such as "2023.05.R02" or "2024.02.PRE-13".
+// Release is empty when only the year and month are known.
+type Version struct {
+	Year    int
+	Month   int
+	Release string
+}
+
+var (
+	versionPattern = regexp.MustCompile(`^(\d{4})\.(\d{2})(?:\.([A-Z]+-?\d+))?`)
+	releasePattern = regexp.MustCompile(`^([A-Z]+)-?(\d+)$`)
+)
+
+// ParseVersion reads a Smile CDR release name. Build suffixes after the release,
+// e.g. "-SNAPSHOT", are ignored.
+func ParseVersion(text string) (Version, error) {
+	match := versionPattern.FindStringSubmatch(text)
+	if match == nil {
+		return Version{}, fmt.Errorf("%q is not a Smile CDR version, expected e.g. 2023.05.R02", text)
+	}
+	year, _ := strconv.Atoi(match[1])
+	month, _ := strconv.Atoi(match[2])
+	if month < 1 || month > 12 {
+		return Version{}, fmt.Errorf("%q is not a Smile CDR version, %s is not a month", text, match[2])
+	}
+	return Version{Year: year, Month: month, Release: match[3]}, nil
+}
+
+// IsZero reports whether the version is unknown.
+func (v Version) IsZero() bool {
+	return v.Year == 0
+}
+
+func (v Version) String() string {
+	if v.Release == "" {
+		return fmt.Sprintf("%04d.%02d", v.Year, v.Month)
+	}
+	return fmt.Sprintf("%04d.%02d.%s", v.Year, v.Month, v.Release)
+}
+
+// Compare returns -1, 0 or 1 as v is older than, the same as or newer than other.
+// Releases are only compared when both versions have one, so "2023.05" matches
+// every 2023.05 release. Pre-releases come before the R releases of their month.
+func (v Version) Compare(other Version) int {
+	switch {
+	case v.Year != other.Year:
+		return compareInts(v.Year, other.Year)
+	case v.Month != other.Month:
+		return compareInts(v.Month, other.Month)
+	case v.Release == "" || other.Release == "":
+		return 0
+	}
+
+	kind, number := splitRelease(v.Release)
+	otherKind, otherNumber := splitRelease(other.Release)
+	if kind != otherKind {
+		if kind == "PRE" {
+			return -1
+		}
+		if otherKind == "PRE" {
+			return 1
+		}
+	}
+	return compareInts(number, otherNumber)
+}
+
+func splitRelease(release string) (string, int) {
+	match := releasePattern.FindStringSubmatch(release)
+	if match == nil {
+		return release, 0
+	}
+	number, _ := strconv.Atoi(match[2])
+	return match[1], number
+}
+
+func compareInts(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// DetectVersion reads the version of the cluster from the runtime status. During a
+// rolling upgrade the oldest node wins, as every node must support the
+// configuration. Version caches the result.
+func (smilecdr *Client) DetectVersion() (Version, error) {
+	statuses, err := smilecdr.GetNodeStatuses()
+	if err != nil {
+		return Version{}, err
+	}
+
+	nodeIds := make([]string, 0, len(statuses))
+	for nodeId := range statuses {
+		nodeIds = append(nodeIds, nodeId)
+	}
+	sort.Strings(nodeIds)
+
+	var oldest Version
+	for _, nodeId := range nodeIds {
+		node := statuses[nodeId]
+		text := node.Version()
+		if text == "" {
+			continue
+		}
+		version, err := ParseVersion(text)
+		if err != nil {
+			return Version{}, fmt.Errorf("node %s: %s", nodeId, err)
+		}
+		if oldest.IsZero() || version.Compare(oldest) < 0 {
+			oldest = version
+		}
+	}
+	if oldest.IsZero() {
+		return oldest, fmt.Errorf("smilecdr: no node reported its version")
+	}
+
+	return oldest, nil
+}
@@ -0,0 +1,63 @@
+package smilecdr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_VersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2023.05.R02", "2023.05.R01", 1},
+		{"2023.05.PRE-13", "2023.05.R01", -1},
+		{"2023.02.R03", "2023.05", -1},
+		{"2023.05.R02", "2023.05", 0},
+		{"2024.02.R01-SNAPSHOT", "2023.11.R05", 1},
+	}
+	for _, tt := range tests {
+		a, err := ParseVersion(tt.a)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		b, err := ParseVersion(tt.b)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%s compared to %s: got %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if _, err := ParseVersion("6.4.0"); err == nil {
+		t.Fatal("expected an error for a version that is not a Smile CDR release")
+	}
+}
+
+func Test_ClientVersionDetectsOnce(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"nodeStatuses":{"Master":{"processes":{"Master-1":{"nodeVersion":"2023.05.R02"}}}}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "admin", "password")
+	if requests != 0 {
+		t.Fatalf("expected no request before the version is needed, got %d", requests)
+	}
+	for i := 0; i < 2; i++ {
+		version, err := c.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version.String() != "2023.05.R02" {
+			t.Fatalf("unexpected version %s", version)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected the version to be detected once, got %d requests", requests)
+	}
+}